@@ -0,0 +1,328 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Operator compares a summary statistic with the bound of an assertion
+type Operator string
+
+const (
+	// AtLeast requires the statistic to be greater than or equal to the bound
+	AtLeast Operator = ">="
+	// GreaterThan requires the statistic to be strictly greater than the bound
+	GreaterThan Operator = ">"
+	// AtMost requires the statistic to be less than or equal to the bound
+	AtMost Operator = "<="
+	// LessThan requires the statistic to be strictly less than the bound
+	LessThan Operator = "<"
+)
+
+// BoundMode identifies how the bound of an assertion is derived from its threshold
+type BoundMode string
+
+const (
+	// AbsoluteBound uses the threshold as the bound
+	AbsoluteBound BoundMode = ""
+	// DeltaBound adds the threshold to the statistic of the baseline, e.g. -0.02 tolerates a drop of 0.02
+	DeltaBound BoundMode = "delta"
+	// RatioBound multiplies the statistic of the baseline by the threshold, e.g. 0.95 tolerates a drop of 5%
+	RatioBound BoundMode = "ratio"
+)
+
+// Assertion checks a statistic of a metric's summary against a bound, e.g. that the mean of
+// quotes_presence is at least 0.9 or that the p95 of length_ratio is at most 1.5
+type Assertion struct {
+	Metric string
+	// Statistic is one of value, mean, median, stddev, min, max, count, nan_count, error_count,
+	// error_rate or a summary percentile like p95, the empty string means value
+	Statistic string
+	Operator  Operator
+	Threshold float64
+	// Mode makes the bound relative to the same statistic of a baseline summary
+	Mode BoundMode
+}
+
+// assertionOperators are tried in order when parsing, longer operators first
+var assertionOperators = []Operator{AtLeast, AtMost, GreaterThan, LessThan}
+
+// ParseAssertion parses an assertion written as "metric[.statistic] operator bound", where bound is
+// a number, "baseline", "baseline+number", "baseline-number" or "baseline*number", e.g.
+// "quotes_presence.mean >= 0.9", "length_ratio.p95 <= 1.5" or "word_overlap >= baseline-0.02".
+// A name ending like a statistic is split, so "quote_stats.count" is the count of quote_stats until
+// Resolve finds an output or composite child named quote_stats.count in the summary. Writing the
+// statistic, e.g. "quote_stats.count.value", always checks that output.
+func ParseAssertion(text string) (Assertion, error) {
+	for _, operator := range assertionOperators {
+		left, right, ok := strings.Cut(text, string(operator))
+		if !ok {
+			continue
+		}
+
+		assertion := Assertion{Operator: operator}
+		assertion.Metric, assertion.Statistic = splitStatistic(strings.TrimSpace(left))
+		if assertion.Metric == "" {
+			return Assertion{}, fmt.Errorf("missing metric in assertion %q", text)
+		}
+
+		bound := strings.ReplaceAll(right, " ", "")
+		var err error
+		switch {
+		case bound == "baseline":
+			assertion.Mode = DeltaBound
+		case strings.HasPrefix(bound, "baseline*"):
+			assertion.Mode = RatioBound
+			assertion.Threshold, err = strconv.ParseFloat(strings.TrimPrefix(bound, "baseline*"), 64)
+		case strings.HasPrefix(bound, "baseline+"), strings.HasPrefix(bound, "baseline-"):
+			assertion.Mode = DeltaBound
+			assertion.Threshold, err = strconv.ParseFloat(strings.TrimPrefix(bound, "baseline"), 64)
+		default:
+			assertion.Threshold, err = strconv.ParseFloat(bound, 64)
+		}
+		if err != nil {
+			return Assertion{}, fmt.Errorf("invalid bound %q in assertion %q", strings.TrimSpace(right), text)
+		}
+		return assertion, nil
+	}
+	return Assertion{}, fmt.Errorf("missing operator in assertion %q, expected one of >=, >, <= or <", text)
+}
+
+// splitStatistic splits "metric.statistic" into its parts, leaving names whose
+// suffix is not a known statistic as metric names
+func splitStatistic(name string) (metric, statistic string) {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return name, ""
+	}
+	if _, ok := summaryStatistic(MetricSummary{}, name[i+1:]); !ok && !isPercentileName(name[i+1:]) {
+		return name, ""
+	}
+	return name[:i], name[i+1:]
+}
+
+// isPercentileName reports whether name looks like a percentile such as "p95" or "p99.9"
+func isPercentileName(name string) bool {
+	if !strings.HasPrefix(name, "p") {
+		return false
+	}
+	_, err := strconv.ParseFloat(name[1:], 64)
+	return err == nil
+}
+
+// Resolve returns the assertion checked against summary. An assertion whose metric and statistic join into
+// the name of a metric of summary, e.g. the output quote_stats.count or the composite child quality.min,
+// checks the value of that metric, since result keys take precedence over statistics.
+func (a Assertion) Resolve(summary Summary) Assertion {
+	if a.Statistic == "" {
+		return a
+	}
+	if _, ok := summary.Metric(a.Metric + "." + a.Statistic); ok {
+		a.Metric, a.Statistic = a.Metric+"."+a.Statistic, ""
+	}
+	return a
+}
+
+// String formats the assertion in the syntax accepted by ParseAssertion
+func (a Assertion) String() string {
+	name := a.Metric
+	if a.Statistic != "" {
+		name += "." + a.Statistic
+	}
+
+	bound := strconv.FormatFloat(a.Threshold, 'g', -1, 64)
+	switch a.Mode {
+	case DeltaBound:
+		switch {
+		case a.Threshold == 0:
+			bound = "baseline"
+		case a.Threshold > 0:
+			bound = "baseline+" + bound
+		default:
+			bound = "baseline" + bound
+		}
+	case RatioBound:
+		bound = "baseline*" + bound
+	}
+	return fmt.Sprintf("%s %s %s", name, a.Operator, bound)
+}
+
+// AssertionResult holds the outcome of a single assertion
+type AssertionResult struct {
+	Assertion Assertion
+	// Actual is the checked statistic of the summary
+	Actual float64
+	// Bound is the value the statistic was compared with
+	Bound float64
+	// Baseline is the statistic of the baseline summary, only set for relative assertions
+	Baseline float64
+	Passed   bool
+	// Err explains why the assertion could not be checked, e.g. a metric missing from the summary
+	Err error
+}
+
+// String describes the outcome, e.g. "FAIL quotes_presence.mean >= 0.9: got 0.85"
+func (r AssertionResult) String() string {
+	status := "PASS"
+	if !r.Passed {
+		status = "FAIL"
+	}
+	if r.Err != nil {
+		return fmt.Sprintf("%s %s: %v", status, r.Assertion, r.Err)
+	}
+	if r.Assertion.Mode != AbsoluteBound {
+		return fmt.Sprintf("%s %s: got %.4g, bound %.4g from baseline %.4g", status, r.Assertion, r.Actual, r.Bound, r.Baseline)
+	}
+	return fmt.Sprintf("%s %s: got %.4g", status, r.Assertion, r.Actual)
+}
+
+// Verdict holds the outcome of checking assertions against a summary
+type Verdict struct {
+	// Passed is set when every assertion passed
+	Passed  bool
+	Results []AssertionResult
+}
+
+// Failures returns the results of the violated assertions
+func (v Verdict) Failures() []AssertionResult {
+	var failures []AssertionResult
+	for _, result := range v.Results {
+		if !result.Passed {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Err returns an error listing the violated assertions, or nil when every assertion passed
+func (v Verdict) Err() error {
+	failures := v.Failures()
+	if len(failures) == 0 {
+		return nil
+	}
+
+	lines := make([]string, len(failures))
+	for i, failure := range failures {
+		lines[i] = failure.String()
+	}
+	return fmt.Errorf("%d of %d assertions failed:\n%s", len(failures), len(v.Results), strings.Join(lines, "\n"))
+}
+
+// CheckAssertions checks assertions, resolved against summary, against a summary. Relative assertions are
+// checked against the same statistic of baseline, they fail when baseline is nil. Assertions on missing metrics,
+// unknown statistics or NaN statistics fail.
+func CheckAssertions(summary Summary, baseline *Summary, assertions []Assertion) Verdict {
+	verdict := Verdict{Passed: true, Results: make([]AssertionResult, len(assertions))}
+	for i, assertion := range assertions {
+		result := checkAssertion(summary, baseline, assertion)
+		verdict.Results[i] = result
+		verdict.Passed = verdict.Passed && result.Passed
+	}
+	return verdict
+}
+
+func checkAssertion(summary Summary, baseline *Summary, assertion Assertion) AssertionResult {
+	result := AssertionResult{Assertion: assertion}
+	assertion = assertion.Resolve(summary)
+
+	actual, err := assertedStatistic(summary, assertion)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Actual = actual
+
+	switch assertion.Mode {
+	case AbsoluteBound:
+		result.Bound = assertion.Threshold
+	case DeltaBound, RatioBound:
+		if baseline == nil {
+			result.Err = errors.New("no baseline to compare with")
+			return result
+		}
+		base, err := assertedStatistic(*baseline, assertion)
+		if err != nil {
+			result.Err = fmt.Errorf("baseline: %w", err)
+			return result
+		}
+		result.Baseline = base
+		if assertion.Mode == DeltaBound {
+			result.Bound = base + assertion.Threshold
+		} else {
+			result.Bound = base * assertion.Threshold
+		}
+	default:
+		result.Err = fmt.Errorf("unknown bound mode %q", assertion.Mode)
+		return result
+	}
+
+	switch assertion.Operator {
+	case AtLeast:
+		result.Passed = actual >= result.Bound
+	case GreaterThan:
+		result.Passed = actual > result.Bound
+	case AtMost:
+		result.Passed = actual <= result.Bound
+	case LessThan:
+		result.Passed = actual < result.Bound
+	default:
+		result.Err = fmt.Errorf("unknown operator %q", assertion.Operator)
+	}
+	return result
+}
+
+// assertedStatistic returns the statistic an assertion checks in summary
+func assertedStatistic(summary Summary, assertion Assertion) (float64, error) {
+	metric, ok := summary.Metric(assertion.Metric)
+	if !ok {
+		return 0, fmt.Errorf("metric %s is missing from the summary", assertion.Metric)
+	}
+
+	statistic := assertion.Statistic
+	if statistic == "" {
+		statistic = "value"
+	}
+	value, ok := summaryStatistic(metric, statistic)
+	if !ok {
+		return 0, fmt.Errorf("unknown statistic %q", statistic)
+	}
+	if math.IsNaN(value) {
+		return 0, fmt.Errorf("%s of %s is NaN", statistic, assertion.Metric)
+	}
+	return value, nil
+}
+
+// summaryStatistic returns the named statistic of a metric summary
+func summaryStatistic(summary MetricSummary, name string) (float64, bool) {
+	switch name {
+	case "value":
+		return summary.Value, true
+	case "mean":
+		return summary.Mean, true
+	case "median":
+		return summary.Median, true
+	case "stddev":
+		return summary.StdDev, true
+	case "min":
+		return summary.Min, true
+	case "max":
+		return summary.Max, true
+	case "count":
+		return float64(summary.Count), true
+	case "nan_count":
+		return float64(summary.NaNCount), true
+	case "error_count":
+		return float64(summary.ErrorCount), true
+	case "error_rate":
+		total := summary.Count + summary.NaNCount + summary.ErrorCount
+		if total == 0 {
+			return 0, true
+		}
+		return float64(summary.ErrorCount) / float64(total), true
+	}
+	value, ok := summary.Percentiles[name]
+	return value, ok
+}
@@ -0,0 +1,81 @@
+package eval
+
+import "testing"
+
+func TestParseAssertion(t *testing.T) {
+	tests := []struct {
+		text string
+		want Assertion
+	}{
+		{"quotes_presence.mean >= 0.9", Assertion{Metric: "quotes_presence", Statistic: "mean", Operator: AtLeast, Threshold: 0.9}},
+		{"length_ratio.p95<=1.5", Assertion{Metric: "length_ratio", Statistic: "p95", Operator: AtMost, Threshold: 1.5}},
+		{"word_overlap > baseline", Assertion{Metric: "word_overlap", Operator: GreaterThan, Mode: DeltaBound}},
+		{"word_overlap >= baseline - 0.02", Assertion{Metric: "word_overlap", Operator: AtLeast, Threshold: -0.02, Mode: DeltaBound}},
+		{"rouge_l.recall < baseline*1.1", Assertion{Metric: "rouge_l.recall", Operator: LessThan, Threshold: 1.1, Mode: RatioBound}},
+		{"quote_stats.count.value >= 1", Assertion{Metric: "quote_stats.count", Statistic: "value", Operator: AtLeast, Threshold: 1}},
+	}
+	for _, tt := range tests {
+		got, err := ParseAssertion(tt.text)
+		if err != nil {
+			t.Errorf("ParseAssertion(%q) failed: %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAssertion(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+		if reparsed, err := ParseAssertion(got.String()); err != nil || reparsed != got {
+			t.Errorf("ParseAssertion(%q) = %+v, %v, want %+v", got.String(), reparsed, err, got)
+		}
+	}
+
+	for _, text := range []string{"word_overlap", ">= 1", "word_overlap >= high", "word_overlap >= baseline/2"} {
+		if _, err := ParseAssertion(text); err == nil {
+			t.Errorf("ParseAssertion(%q) succeeded, want an error", text)
+		}
+	}
+}
+
+func TestCheckAssertions(t *testing.T) {
+	summary := Summary{Metrics: []MetricSummary{
+		{Metric: "word_overlap", Value: 0.6, Mean: 0.6, Min: 0.1, Count: 9, ErrorCount: 1},
+		{Metric: "quote_stats.count", Value: 2, Mean: 2, Min: 0, Count: 10},
+		{Metric: "quality", Value: 0.5, Min: 0.2, Count: 10},
+		{Metric: "quality.min", Value: 0.7, Count: 10},
+	}}
+	baseline := Summary{Metrics: []MetricSummary{
+		{Metric: "word_overlap", Value: 0.7, Mean: 0.7},
+	}}
+
+	tests := []struct {
+		text   string
+		passed bool
+	}{
+		{"word_overlap >= 0.6", true},
+		{"word_overlap.mean > 0.6", false},
+		{"word_overlap.error_rate <= 0.1", true},
+		{"word_overlap >= baseline-0.2", true},
+		{"word_overlap >= baseline*0.9", false},
+		{"quote_stats.count >= 2", true},
+		{"quote_stats.count.value >= 2", true},
+		{"quote_stats.count.min >= 1", false},
+		{"quality.min >= 0.7", true},
+		{"quality.median >= 0", true},
+		{"missing >= 0", false},
+		{"word_overlap.p42 >= 0", false},
+	}
+	for _, tt := range tests {
+		assertion, err := ParseAssertion(tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verdict := CheckAssertions(summary, &baseline, []Assertion{assertion})
+		if verdict.Passed != tt.passed {
+			t.Errorf("%s: passed = %v, want %v (%s)", tt.text, verdict.Passed, tt.passed, verdict.Results[0])
+		}
+	}
+
+	assertion, _ := ParseAssertion("word_overlap >= baseline")
+	if verdict := CheckAssertions(summary, nil, []Assertion{assertion}); verdict.Passed || verdict.Results[0].Err == nil {
+		t.Errorf("relative assertion without baseline = %+v, want an error", verdict.Results[0])
+	}
+}
@@ -0,0 +1,121 @@
+package eval
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"sort"
+)
+
+// BootstrapOptions configures the bootstrap resampling of metric aggregates
+type BootstrapOptions struct {
+	// Resamples is the number of bootstrap resamples, 0 means 1000
+	Resamples int
+	// Confidence is the confidence level of the intervals in (0, 1), 0 means 0.95
+	Confidence float64
+	// Seed makes the resampling reproducible, the same results and seed always give the same intervals
+	Seed uint64
+}
+
+// ConfidenceInterval is a percentile bootstrap confidence interval around a metric's aggregate
+type ConfidenceInterval struct {
+	Metric string
+	// Aggregator is the name of the aggregator that produced Value and was applied to every resample
+	Aggregator string
+	Value      float64
+	Lower      float64
+	Upper      float64
+	Confidence float64
+	Resamples  int
+	// Count is the number of scores that were resampled, errored and NaN scores are left out
+	Count int
+}
+
+// Bootstrap computes confidence intervals for the aggregate of every metric of this evaluation
+func (e *PairwiseEvaluation) Bootstrap(results []PairwiseResult, opts BootstrapOptions) ([]ConfidenceInterval, error) {
+	order := e.resultKeys()
+	return bootstrap(pairwiseRows(results), order, e.Aggregators(), opts)
+}
+
+// Bootstrap computes confidence intervals for the aggregate of every metric of this evaluation
+func (e *PointwiseEvaluation) Bootstrap(results []PointwiseResult, opts BootstrapOptions) ([]ConfidenceInterval, error) {
+	order := e.resultKeys()
+	return bootstrap(pointwiseRows(results), order, e.Aggregators(), opts)
+}
+
+// BootstrapPairwise computes confidence intervals for the aggregate of every metric in pairwise results.
+// Metrics missing from aggregators are aggregated with MeanAggregator.
+func BootstrapPairwise(results []PairwiseResult, aggregators map[string]Aggregator, opts BootstrapOptions) ([]ConfidenceInterval, error) {
+	return bootstrap(pairwiseRows(results), nil, aggregators, opts)
+}
+
+// BootstrapPointwise computes confidence intervals for the aggregate of every metric in pointwise results.
+// Metrics missing from aggregators are aggregated with MeanAggregator.
+func BootstrapPointwise(results []PointwiseResult, aggregators map[string]Aggregator, opts BootstrapOptions) ([]ConfidenceInterval, error) {
+	return bootstrap(pointwiseRows(results), nil, aggregators, opts)
+}
+
+func bootstrap(rows []row, order []string, aggregators map[string]Aggregator, opts BootstrapOptions) ([]ConfidenceInterval, error) {
+	if opts.Resamples == 0 {
+		opts.Resamples = 1000
+	}
+	if opts.Confidence == 0 {
+		opts.Confidence = 0.95
+	}
+	if opts.Resamples < 0 {
+		return nil, fmt.Errorf("invalid number of resamples %d", opts.Resamples)
+	}
+	if opts.Confidence <= 0 || opts.Confidence >= 1 {
+		return nil, fmt.Errorf("confidence level %g is not between 0 and 1", opts.Confidence)
+	}
+
+	names := metricNames(rows, order)
+	intervals := make([]ConfidenceInterval, len(names))
+	for i, name := range names {
+		scores, _, _ := metricScores(rows, name)
+		intervals[i] = bootstrapScores(name, scores, aggregators[name], opts)
+	}
+	return intervals, nil
+}
+
+// bootstrapScores resamples scores with replacement and takes the percentile interval of the
+// resampled aggregates. The random stream is derived from the seed and the metric name so an
+// interval does not depend on which other metrics were resampled.
+func bootstrapScores(name string, scores []float64, aggregator Aggregator, opts BootstrapOptions) ConfidenceInterval {
+	if aggregator.Aggregate == nil {
+		aggregator = MeanAggregator
+	}
+
+	interval := ConfidenceInterval{
+		Metric:     name,
+		Aggregator: aggregator.Name,
+		Confidence: opts.Confidence,
+		Resamples:  opts.Resamples,
+		Count:      len(scores),
+	}
+	if len(scores) == 0 {
+		return interval
+	}
+
+	interval.Value = aggregator.Aggregate(scores)
+
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	rng := rand.New(rand.NewPCG(opts.Seed, hash.Sum64()))
+
+	sample := make([]float64, len(scores))
+	aggregates := make([]float64, opts.Resamples)
+	for r := range aggregates {
+		for i := range sample {
+			sample[i] = scores[rng.IntN(len(scores))]
+		}
+		aggregates[r] = aggregator.Aggregate(sample)
+	}
+	sort.Float64s(aggregates)
+
+	alpha := 1 - opts.Confidence
+	interval.Lower = percentile(aggregates, 100*alpha/2)
+	interval.Upper = percentile(aggregates, 100*(1-alpha/2))
+
+	return interval
+}
@@ -0,0 +1,94 @@
+package eval
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBootstrapPointwise(t *testing.T) {
+	scores := make([]float64, 1000)
+	for i := range scores {
+		scores[i] = float64(i) / 999
+	}
+	results := pointwiseScores("uniform", scores)
+
+	intervals, err := BootstrapPointwise(results, nil, BootstrapOptions{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interval := intervals[0]
+	if interval.Metric != "uniform" || interval.Aggregator != "mean" || interval.Resamples != 1000 || interval.Confidence != 0.95 || interval.Count != 1000 {
+		t.Errorf("interval = %+v", interval)
+	}
+	if !closeTo(interval.Value, 0.5) || interval.Lower >= 0.5 || interval.Upper <= 0.5 {
+		t.Errorf("interval [%g, %g] does not surround the mean %g", interval.Lower, interval.Upper, interval.Value)
+	}
+	// The standard error of the mean is about 0.2887 / sqrt(1000), so the interval spans about 2 * 1.96 * 0.00913
+	if width := interval.Upper - interval.Lower; math.Abs(width-0.0358) > 0.005 {
+		t.Errorf("interval width = %g, want about 0.0358", width)
+	}
+
+	again, _ := BootstrapPointwise(results, nil, BootstrapOptions{Seed: 1})
+	if again[0] != interval {
+		t.Errorf("intervals with the same seed differ: %+v and %+v", again[0], interval)
+	}
+	other, _ := BootstrapPointwise(results, nil, BootstrapOptions{Seed: 2})
+	if other[0] == interval {
+		t.Error("intervals with different seeds are identical")
+	}
+}
+
+func TestBootstrapMetricsAreIndependent(t *testing.T) {
+	results := pointwiseScores("a", []float64{0.1, 0.5, 0.9, 0.3})
+	alone, err := BootstrapPointwise(results, nil, BootstrapOptions{Seed: 3, Resamples: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range results {
+		results[i].MetricResults["b"] = float64(i)
+	}
+	results[0].MetricResults["c"] = math.NaN()
+	results[1].Errors = MetricErrors{"c": errTest}
+	together, err := BootstrapPointwise(results, map[string]Aggregator{"b": MaxAggregator}, BootstrapOptions{Seed: 3, Resamples: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	intervals := make(map[string]ConfidenceInterval)
+	for _, interval := range together {
+		intervals[interval.Metric] = interval
+	}
+	if intervals["a"] != alone[0] {
+		t.Errorf("interval of a changed with other metrics: %+v, want %+v", intervals["a"], alone[0])
+	}
+	if b := intervals["b"]; b.Aggregator != "max" || b.Value != 3 || b.Upper != 3 {
+		t.Errorf("interval of b = %+v, want the max aggregator with value 3", b)
+	}
+	if c := intervals["c"]; c.Count != 0 || c.Value != 0 {
+		t.Errorf("interval of c = %+v, want no scores", c)
+	}
+}
+
+func TestBootstrapConstantScores(t *testing.T) {
+	intervals, err := BootstrapPointwise(pointwiseScores("m", []float64{0.7, 0.7, 0.7}), nil, BootstrapOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if interval := intervals[0]; !closeTo(interval.Lower, 0.7) || !closeTo(interval.Upper, 0.7) {
+		t.Errorf("interval = [%g, %g], want [0.7, 0.7]", interval.Lower, interval.Upper)
+	}
+}
+
+func TestBootstrapOptions(t *testing.T) {
+	results := pointwiseScores("m", []float64{1, 2})
+	for _, opts := range []BootstrapOptions{
+		{Resamples: -1},
+		{Confidence: 1},
+		{Confidence: -0.5},
+	} {
+		if _, err := BootstrapPointwise(results, nil, opts); err == nil {
+			t.Errorf("BootstrapPointwise with %+v succeeded, want an error", opts)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// Command eval-go runs pairwise or pointwise evaluations over a dataset file and writes their results.
+//
+// Usage:
+//
+//	eval-go -data instances.jsonl -metric word_overlap -metric short_quotes_count:threshold=5 [flags]
+//
+// Metrics are selected by name, optionally followed by a colon and comma-separated key=value
+// parameters. A comma within a value is escaped with a backslash, e.g. keyword_presence:keywords=a\,b|c,
+// as is a backslash preceding a comma. In pairwise mode pointwise metrics are converted with the
+// score function named by their score parameter, e.g. quotes_count:score=ratio, the difference by default.
+//
+// Evaluations can also be defined in a YAML or JSON file passed with -config, see package config
+// for its format. Flags set alongside -config override the definition and -metric adds to its metrics.
+//
+// Assertions given with -assert or in the definition gate the run on its summary, e.g. for CI:
+// the tool exits with status 3 when one of them is violated. Relative assertions compare with the
+// summary given with -baseline.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	eval "github.com/snpu/eval-go"
+	"github.com/snpu/eval-go/config"
+	"github.com/snpu/eval-go/dataset"
+	"github.com/snpu/eval-go/report"
+)
+
+// Exit codes
+const (
+	exitOK = iota
+	exitFailure
+	exitUsage
+	// exitAssertions reports a run that completed but violated its assertions
+	exitAssertions
+)
+
+// stringList collects the values of a repeated flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("eval-go", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var metricSpecs, assertionSpecs stringList
+	var mapping dataset.FieldMapping
+	data := flags.String("data", "", "dataset file to evaluate (.jsonl, .ndjson, .csv or .tsv)")
+	configFile := flags.String("config", "", "evaluation definition to run (.yaml, .yml or .json), overridden by the flags set alongside it")
+	mode := flags.String("mode", config.Pairwise, "evaluation mode, pairwise or pointwise")
+	name := flags.String("name", "eval-go", "evaluation name")
+	description := flags.String("description", "", "evaluation description")
+	format := flags.String("format", "markdown", "output format: markdown, json, jsonl, csv, summary-json, summary-csv or html")
+	output := flags.String("out", "", "output file, standard output by default")
+	concurrency := flags.Int("concurrency", 1, "maximum number of metric computations run at once")
+	shardSize := flags.Int("shard-size", 0, "number of instances per metric computation, 0 computes each metric over the whole dataset")
+	partial := flags.Bool("partial", false, "record metric failures in the results instead of aborting")
+	baseline := flags.String("baseline", "", "summary JSON of a previous run, written with -format json or summary-json, to check relative assertions against")
+	list := flags.Bool("list", false, "list the available metrics and exit")
+	flags.Var(&metricSpecs, "metric", "metric to compute as name or name:key=value,..., with commas in values escaped as \\, (repeatable)")
+	flags.Var(&assertionSpecs, "assert", "assertion on the run summary, e.g. \"quotes_presence.mean >= 0.9\" or \"word_overlap >= baseline-0.02\" (repeatable)")
+	flags.StringVar(&mapping.ID, "id-field", "", "dataset field holding the instance ID (default \"id\")")
+	flags.StringVar(&mapping.Reference, "reference-field", "", "dataset field holding the reference (default \"reference\")")
+	flags.StringVar(&mapping.Prediction, "prediction-field", "", "dataset field holding the prediction (default \"prediction\")")
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if *list {
+		listMetrics(stdout)
+		return exitOK
+	}
+
+	if *data == "" {
+		fmt.Fprintln(stderr, "eval-go: -data is required")
+		flags.Usage()
+		return exitUsage
+	}
+	if len(metricSpecs) == 0 && *configFile == "" {
+		fmt.Fprintln(stderr, "eval-go: at least one -metric or a -config is required, use -list to see the available metrics")
+		return exitUsage
+	}
+
+	write, err := writerFor(*format)
+	if err != nil {
+		fmt.Fprintf(stderr, "eval-go: %v\n", err)
+		return exitUsage
+	}
+
+	// Start from the definition file, if any, and apply the flags set on the command line
+	definition := &config.Evaluation{Name: *name, Mode: *mode, Concurrency: *concurrency}
+	if *configFile != "" {
+		if definition, err = config.Load(*configFile); err != nil {
+			fmt.Fprintf(stderr, "eval-go: %v\n", err)
+			return exitUsage
+		}
+	}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "mode":
+			definition.Mode = *mode
+		case "name":
+			definition.Name = *name
+		case "description":
+			definition.Description = *description
+		case "concurrency":
+			definition.Concurrency = *concurrency
+		case "shard-size":
+			definition.ShardSize = *shardSize
+		case "partial":
+			definition.PartialFailures = *partial
+		}
+	})
+	for _, raw := range metricSpecs {
+		metric, err := parseMetricSpec(raw)
+		if err != nil {
+			fmt.Fprintf(stderr, "eval-go: %v\n", err)
+			return exitUsage
+		}
+		definition.Metrics = append(definition.Metrics, metric)
+	}
+	definition.Assertions = append(definition.Assertions, assertionSpecs...)
+	if err := definition.Validate(); err != nil {
+		fmt.Fprintf(stderr, "eval-go: %v\n", err)
+		return exitUsage
+	}
+
+	assertions, err := definition.ParseAssertions()
+	if err != nil {
+		fmt.Fprintf(stderr, "eval-go: %v\n", err)
+		return exitUsage
+	}
+	var baselineSummary *eval.Summary
+	if *baseline != "" {
+		summary, err := readSummary(*baseline)
+		if err != nil {
+			fmt.Fprintf(stderr, "eval-go: baseline: %v\n", err)
+			return exitUsage
+		}
+		baselineSummary = &summary
+	}
+
+	instances, err := dataset.Load(*data, mapping)
+	if err != nil {
+		fmt.Fprintf(stderr, "eval-go: %v\n", err)
+		return exitFailure
+	}
+
+	var result report.Run
+	if definition.Mode == config.Pointwise {
+		evaluation, err := definition.Pointwise()
+		if err != nil {
+			fmt.Fprintf(stderr, "eval-go: %v\n", err)
+			return exitUsage
+		}
+		results, err := evaluation.RunInstances(ctx, eval.PointwiseInstances(instances))
+		if err != nil {
+			fmt.Fprintf(stderr, "eval-go: %v\n", err)
+			return exitFailure
+		}
+		result = report.NewPointwiseRun(evaluation, results)
+	} else {
+		evaluation, err := definition.Pairwise()
+		if err != nil {
+			fmt.Fprintf(stderr, "eval-go: %v\n", err)
+			return exitUsage
+		}
+		results, err := evaluation.Run(ctx, instances)
+		if err != nil {
+			fmt.Fprintf(stderr, "eval-go: %v\n", err)
+			return exitFailure
+		}
+		result = report.NewPairwiseRun(evaluation, results)
+	}
+
+	if err := writeOutput(write, result, *output, stdout); err != nil {
+		fmt.Fprintf(stderr, "eval-go: %v\n", err)
+		return exitFailure
+	}
+
+	if len(assertions) > 0 {
+		verdict := eval.CheckAssertions(*result.Summary, baselineSummary, assertions)
+		for _, assertion := range verdict.Results {
+			fmt.Fprintln(stderr, assertion)
+		}
+		if !verdict.Passed {
+			fmt.Fprintf(stderr, "eval-go: %d of %d assertions failed\n", len(verdict.Failures()), len(verdict.Results))
+			return exitAssertions
+		}
+	}
+	return exitOK
+}
+
+// readSummary reads a summary JSON file
+func readSummary(path string) (eval.Summary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return eval.Summary{}, err
+	}
+	defer file.Close()
+	return report.ReadSummaryJSON(file)
+}
+
+// listMetrics writes the metrics of the default registry along with their parameters
+func listMetrics(w io.Writer) {
+	for _, factory := range eval.DefaultRegistry.Factories() {
+		kind := config.Pointwise
+		if factory.Pairwise() {
+			kind = config.Pairwise
+		}
+		fmt.Fprintf(w, "%-22s %-9s %s\n", factory.Name, kind, factory.Description)
+		for _, param := range factory.Params {
+			fmt.Fprintf(w, "%-22s %-9s %s", "", "", param.Name+" "+param.Type.String())
+			if param.Description != "" {
+				fmt.Fprintf(w, ": %s", param.Description)
+			}
+			if param.Required {
+				fmt.Fprint(w, " (required)")
+			} else if param.Default != nil {
+				fmt.Fprintf(w, " (default %v)", param.Default)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// writerFor returns the report writer of an output format
+func writerFor(format string) (func(io.Writer, report.Run) error, error) {
+	switch format {
+	case "markdown":
+		return report.WriteMarkdown, nil
+	case "json":
+		return report.WriteJSON, nil
+	case "jsonl":
+		return report.WriteJSONL, nil
+	case "csv":
+		return report.WriteCSV, nil
+	case "summary-json":
+		return func(w io.Writer, run report.Run) error {
+			return report.WriteSummaryJSON(w, *run.Summary)
+		}, nil
+	case "summary-csv":
+		return func(w io.Writer, run report.Run) error {
+			return report.WriteSummaryCSV(w, *run.Summary)
+		}, nil
+	case "html":
+		return report.WriteHTML, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// writeOutput writes the run to the output file, or to stdout when output is empty
+func writeOutput(write func(io.Writer, report.Run) error, run report.Run, output string, stdout io.Writer) error {
+	if output == "" {
+		return write(stdout, run)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := write(file, run); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
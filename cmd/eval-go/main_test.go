@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseMetricSpec(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"word_overlap", "{word_overlap map[]   }"},
+		{"short_quotes_count: threshold = 5 ", "{short_quotes_count map[threshold:5]   }"},
+		{"quotes_count:score=ratio,aggregator=max", "{quotes_count map[] ratio max }"},
+		{"rouge1:reference_reducer=mean,stemming=true", "{rouge1 map[stemming:true]   mean}"},
+		{`keyword_presence:keywords=a\,b|c,score=ratio`, "{keyword_presence map[keywords:a,b|c] ratio  }"},
+		{`keyword_presence:keywords=a\\\,b\c\\`, `{keyword_presence map[keywords:a\,b\c\]   }`},
+	}
+	for _, tt := range tests {
+		metric, err := parseMetricSpec(tt.spec)
+		if err != nil {
+			t.Errorf("parseMetricSpec(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := fmt.Sprint(metric); got != tt.want {
+			t.Errorf("parseMetricSpec(%q) = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"", ":threshold=5", "short_quotes_count:threshold", "short_quotes_count:=5"} {
+		if _, err := parseMetricSpec(spec); err == nil {
+			t.Errorf("parseMetricSpec(%q) accepted an invalid spec", spec)
+		}
+	}
+}
+
+// writeFile writes content to name in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	data := writeFile(t, "data.jsonl", `{"id": "1", "reference": "the cat sat", "prediction": "the cat sat"}
+{"id": "2", "reference": "the cat sat", "prediction": "a dog ran"}
+`)
+	definition := writeFile(t, "eval.yaml", "name: from-config\nmetrics:\n  - name: word_overlap\n")
+
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{
+			name:   "csv",
+			args:   []string{"-data", data, "-metric", "word_overlap", "-metric", "keyword_presence:keywords=cat,score=absolute_difference", "-format", "csv"},
+			stdout: "id,reference,prediction,tags,word_overlap,keyword_presence\n1,the cat sat,the cat sat,,1,0\n2,the cat sat,a dog ran,,0,1\n",
+		},
+		{
+			name:   "markdown",
+			args:   []string{"-data", data, "-metric", "word_overlap", "-name", "overlap"},
+			stdout: "## overlap\n",
+		},
+		{
+			name:   "config with flag override",
+			args:   []string{"-data", data, "-config", definition, "-name", "overridden"},
+			stdout: "## overridden\n\n| Metric |",
+		},
+		{
+			name:   "passing assertion",
+			args:   []string{"-data", data, "-metric", "word_overlap", "-assert", "word_overlap.max >= 1", "-format", "jsonl"},
+			stderr: "PASS",
+		},
+		{
+			name:   "failing assertion",
+			args:   []string{"-data", data, "-metric", "word_overlap", "-assert", "word_overlap >= 0.9"},
+			code:   exitAssertions,
+			stderr: "1 of 1 assertions failed",
+		},
+		{
+			name:   "list",
+			args:   []string{"-list"},
+			stdout: "word_overlap",
+		},
+		{
+			name:   "missing data",
+			args:   []string{"-metric", "word_overlap"},
+			code:   exitUsage,
+			stderr: "-data is required",
+		},
+		{
+			name:   "missing metrics",
+			args:   []string{"-data", data},
+			code:   exitUsage,
+			stderr: "at least one -metric",
+		},
+		{
+			name:   "unknown metric",
+			args:   []string{"-data", data, "-metric", "nope"},
+			code:   exitUsage,
+			stderr: `metrics[0].name: unknown metric "nope"`,
+		},
+		{
+			name:   "unknown format",
+			args:   []string{"-data", data, "-metric", "word_overlap", "-format", "xml"},
+			code:   exitUsage,
+			stderr: `unknown format "xml"`,
+		},
+		{
+			name:   "missing dataset",
+			args:   []string{"-data", filepath.Join(t.TempDir(), "missing.jsonl"), "-metric", "word_overlap"},
+			code:   exitFailure,
+			stderr: "missing.jsonl",
+		},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := run(context.Background(), tt.args, &stdout, &stderr)
+		if code != tt.code {
+			t.Errorf("%s: exit code %d, want %d, stderr:\n%s", tt.name, code, tt.code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), tt.stdout) {
+			t.Errorf("%s: stdout =\n%s\nwant it to contain\n%s", tt.name, stdout.String(), tt.stdout)
+		}
+		if !strings.Contains(stderr.String(), tt.stderr) {
+			t.Errorf("%s: stderr =\n%s\nwant it to contain\n%s", tt.name, stderr.String(), tt.stderr)
+		}
+	}
+}
+
+func TestRunOutputFile(t *testing.T) {
+	data := writeFile(t, "data.csv", "id,prediction\n1,\"a \"\"quote\"\"\"\n")
+	output := filepath.Join(t.TempDir(), "report.html")
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"-data", data, "-mode", "pointwise", "-metric", "quotes_count", "-format", "html", "-out", output}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("exit code %d, stderr:\n%s", code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("stdout = %q, want the report in the output file only", stdout.String())
+	}
+	page, err := os.ReadFile(output)
+	if err != nil || !strings.Contains(string(page), "quotes_count") {
+		t.Errorf("report = %.200s, %v", page, err)
+	}
+}
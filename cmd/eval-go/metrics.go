@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/snpu/eval-go/config"
+)
+
+// parseMetricSpec parses a metric selected on the command line as name or name:key=value,key=value.
+// A backslash escapes a comma or a backslash within a parameter value, e.g. keyword_presence:keywords=a\,b|c.
+// The score, aggregator and reference_reducer keys select the corresponding config fields,
+// every other key is a metric parameter.
+func parseMetricSpec(spec string) (config.Metric, error) {
+	name, rawParams, _ := strings.Cut(spec, ":")
+	metric := config.Metric{Name: strings.TrimSpace(name)}
+	if metric.Name == "" {
+		return config.Metric{}, fmt.Errorf("missing metric name in %q", spec)
+	}
+	if rawParams == "" {
+		return metric, nil
+	}
+
+	for _, param := range splitParams(rawParams) {
+		key, value, ok := strings.Cut(param, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return config.Metric{}, fmt.Errorf("invalid parameter %q of metric %s, expected key=value", param, metric.Name)
+		}
+
+		switch key {
+		case "score":
+			metric.Score = value
+		case "aggregator":
+			metric.Aggregator = value
+		case "reference_reducer":
+			metric.ReferenceReducer = value
+		default:
+			if metric.Params == nil {
+				metric.Params = make(map[string]any)
+			}
+			metric.Params[key] = value
+		}
+	}
+	return metric, nil
+}
+
+// splitParams splits comma-separated parameters, unescaping the commas and backslashes escaped with a backslash.
+// Other backslashes are kept as they are.
+func splitParams(raw string) []string {
+	var params []string
+	var param strings.Builder
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\' && i+1 < len(raw) && (raw[i+1] == ',' || raw[i+1] == '\\'):
+			i++
+			param.WriteByte(raw[i])
+		case raw[i] == ',':
+			params = append(params, param.String())
+			param.Reset()
+		default:
+			param.WriteByte(raw[i])
+		}
+	}
+	return append(params, param.String())
+}
@@ -0,0 +1,211 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Combiner blends the scores of the children of a composite metric on one instance into a single score,
+// given the weight of each child
+type Combiner func(scores, weights []float64) float64
+
+// WeightedMeanCombiner returns the weighted arithmetic mean of the scores, 0 when the weights sum to 0
+func WeightedMeanCombiner(scores, weights []float64) float64 {
+	total, weightSum := 0.0, 0.0
+	for i, score := range scores {
+		total += weights[i] * score
+		weightSum += weights[i]
+	}
+	if weightSum == 0 {
+		return 0
+	}
+	return total / weightSum
+}
+
+// GeometricMeanCombiner returns the weighted geometric mean of the scores. It is 0 as soon as a
+// weighted score is 0 and NaN when a weighted score is negative.
+func GeometricMeanCombiner(scores, weights []float64) float64 {
+	logSum, weightSum := 0.0, 0.0
+	for i, score := range scores {
+		if weights[i] == 0 {
+			continue
+		}
+		if score < 0 {
+			return math.NaN()
+		}
+		if score == 0 {
+			return 0
+		}
+		logSum += weights[i] * math.Log(score)
+		weightSum += weights[i]
+	}
+	if weightSum == 0 {
+		return 0
+	}
+	return math.Exp(logSum / weightSum)
+}
+
+// MinCombiner returns the smallest score among the children with a non-zero weight
+func MinCombiner(scores, weights []float64) float64 {
+	result := math.Inf(1)
+	for i, score := range scores {
+		if weights[i] != 0 {
+			result = math.Min(result, score)
+		}
+	}
+	if math.IsInf(result, 1) {
+		return 0
+	}
+	return result
+}
+
+// WeightedPairwiseMetric is a child of a composite pairwise metric
+type WeightedPairwiseMetric struct {
+	Metric PairwiseMetric
+	Weight float64
+}
+
+// WeightedPointwiseMetric is a child of a composite pointwise metric
+type WeightedPointwiseMetric struct {
+	Metric PointwiseMetric
+	Weight float64
+}
+
+// NewCompositePairwiseMetric creates a pairwise metric blending the scores of its children with combiner.
+// The composite score is recorded under name and the score of each child under "name.child", e.g.
+// "quality" and "quality.word_overlap". An instance fails when any of the children fails on it.
+// Details attached by the children are nested under their names and each child is summarized with its own
+// aggregator. The metric fails to compute when a child has no name or two children share one, e.g. a pointwise
+// metric converted with two score functions, since their scores would overwrite each other.
+func NewCompositePairwiseMetric(name, description string, combiner Combiner, children ...WeightedPairwiseMetric) PairwiseMetric {
+	outputs, weights, outputsErr := compositeOutputs(len(children), func(i int) (string, float64) {
+		return children[i].Metric.Name, children[i].Weight
+	})
+	aggregators := make(map[string]Aggregator, len(children))
+	for _, child := range children {
+		aggregators[child.Metric.Name] = child.Metric.Aggregator
+	}
+
+	return PairwiseMetric{
+		Name:              name,
+		Description:       description,
+		outputs:           outputs,
+		outputAggregators: aggregators,
+		computeOutputs: func(ctx context.Context, references, predictions []string) ([][]float64, error) {
+			if outputsErr != nil {
+				return nil, outputsErr
+			}
+			return combineChildren(outputs[1:], len(predictions), combiner, weights, func(i int) (scores []float64, err error) {
+				nestDetails(ctx, children[i].Metric.Name, func(ctx context.Context) {
+					scores, err = children[i].Metric.Compute(ctx, references, predictions)
+				})
+				return scores, err
+			})
+		},
+		computeOutputsMulti: func(ctx context.Context, references [][]string, predictions []string) ([][]float64, error) {
+			if outputsErr != nil {
+				return nil, outputsErr
+			}
+			return combineChildren(outputs[1:], len(predictions), combiner, weights, func(i int) (scores []float64, err error) {
+				nestDetails(ctx, children[i].Metric.Name, func(ctx context.Context) {
+					scores, err = children[i].Metric.ComputeMultiReference(ctx, references, predictions)
+				})
+				return scores, err
+			})
+		},
+	}
+}
+
+// NewCompositePointwiseMetric creates a pointwise metric blending the scores of its children with combiner.
+// The composite score is recorded under name and the score of each child under "name.child", e.g.
+// "quality" and "quality.quotes_presence". An instance fails when any of the children fails on it.
+// Details attached by the children are nested under their names and each child is summarized with its own
+// aggregator. The metric fails to compute when a child has no name or two children share one, e.g. a pointwise
+// metric converted with two score functions, since their scores would overwrite each other.
+func NewCompositePointwiseMetric(name, description string, combiner Combiner, children ...WeightedPointwiseMetric) PointwiseMetric {
+	outputs, weights, outputsErr := compositeOutputs(len(children), func(i int) (string, float64) {
+		return children[i].Metric.Name, children[i].Weight
+	})
+	aggregators := make(map[string]Aggregator, len(children))
+	for _, child := range children {
+		aggregators[child.Metric.Name] = child.Metric.Aggregator
+	}
+
+	return PointwiseMetric{
+		Name:              name,
+		Description:       description,
+		outputs:           outputs,
+		outputAggregators: aggregators,
+		computeOutputs: func(ctx context.Context, predictions []string) ([][]float64, error) {
+			if outputsErr != nil {
+				return nil, outputsErr
+			}
+			return combineChildren(outputs[1:], len(predictions), combiner, weights, func(i int) (scores []float64, err error) {
+				nestDetails(ctx, children[i].Metric.Name, func(ctx context.Context) {
+					scores, err = children[i].Metric.Compute(ctx, predictions)
+				})
+				return scores, err
+			})
+		},
+	}
+}
+
+// compositeOutputs returns the outputs of a composite metric, its own score followed by its children,
+// along with the weights of the children and an error for a child without a name or sharing the name of another
+func compositeOutputs(n int, child func(i int) (string, float64)) ([]string, []float64, error) {
+	outputs := make([]string, n+1)
+	weights := make([]float64, n)
+	var err error
+	for i := range weights {
+		outputs[i+1], weights[i] = child(i)
+		if err != nil {
+			continue
+		}
+		if outputs[i+1] == "" {
+			err = fmt.Errorf("child %d has no name", i)
+			continue
+		}
+		for _, other := range outputs[1 : i+1] {
+			if other == outputs[i+1] {
+				err = fmt.Errorf("two children are named %s, rename one of them", other)
+			}
+		}
+	}
+	return outputs, weights, err
+}
+
+// combineChildren computes every named child over a batch of n instances and combines their scores,
+// returning the composite scores followed by the scores of each child
+func combineChildren(names []string, n int, combiner Combiner, weights []float64, compute func(i int) ([]float64, error)) ([][]float64, error) {
+	columns := make([][]float64, len(names)+1)
+	var failed InstanceErrors
+	for i, name := range names {
+		scores, err := compute(i)
+		failed, err = collectInstanceErrors(failed, err, len(scores), n)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if len(scores) != n {
+			return nil, fmt.Errorf("%s: returned %d scores for %d instances", name, len(scores), n)
+		}
+		columns[i+1] = scores
+	}
+
+	columns[0] = make([]float64, n)
+	childScores := make([]float64, len(names))
+	for j := 0; j < n; j++ {
+		if _, ok := failed[j]; ok {
+			continue
+		}
+		for i := range childScores {
+			childScores[i] = columns[i+1][j]
+		}
+		columns[0][j] = combiner(childScores, weights)
+	}
+
+	if len(failed) > 0 {
+		return columns, failed
+	}
+	return columns, nil
+}
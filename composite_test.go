@@ -0,0 +1,112 @@
+package eval
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCombiners(t *testing.T) {
+	tests := []struct {
+		name     string
+		combiner Combiner
+		scores   []float64
+		weights  []float64
+		want     float64
+	}{
+		{"weighted mean", WeightedMeanCombiner, []float64{1, 0.5}, []float64{3, 1}, 0.875},
+		{"weighted mean without weights", WeightedMeanCombiner, []float64{1, 0.5}, []float64{0, 0}, 0},
+		{"geometric mean", GeometricMeanCombiner, []float64{0.25, 1}, []float64{1, 1}, 0.5},
+		{"geometric mean of zero", GeometricMeanCombiner, []float64{0, 1}, []float64{1, 1}, 0},
+		{"geometric mean ignoring zero weights", GeometricMeanCombiner, []float64{0, 0.3}, []float64{0, 2}, 0.3},
+		{"min", MinCombiner, []float64{0.2, 0.1, 0.9}, []float64{1, 0, 1}, 0.2},
+	}
+	for _, tt := range tests {
+		if got := tt.combiner(tt.scores, tt.weights); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%s: got %g, want %g", tt.name, got, tt.want)
+		}
+	}
+	if got := GeometricMeanCombiner([]float64{-1, 1}, []float64{1, 1}); !math.IsNaN(got) {
+		t.Errorf("geometric mean of a negative score = %g, want NaN", got)
+	}
+}
+
+func TestCompositePairwiseMetric(t *testing.T) {
+	composite := NewCompositePairwiseMetric("quality", "", WeightedMeanCombiner,
+		WeightedPairwiseMetric{Metric: constantPairwise("high", 1), Weight: 3},
+		WeightedPairwiseMetric{Metric: constantPairwise("low", 0.5), Weight: 1},
+	)
+
+	outputs, err := composite.ComputeOutputs(context.Background(), []string{"a", "b"}, []string{"a", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"": 0.875, "high": 1, "low": 0.5}
+	for output, score := range want {
+		if scores := outputs[output]; len(scores) != 2 || scores[0] != score || scores[1] != score {
+			t.Errorf("output %q = %v, want %g for both instances", output, scores, score)
+		}
+	}
+
+	if keys := strings.Join(composite.ResultKeys(), " "); keys != "quality quality.high quality.low" {
+		t.Errorf("ResultKeys() = %s", keys)
+	}
+}
+
+func TestCompositeRejectsDuplicateChildren(t *testing.T) {
+	length := NewPointwiseMetric("length", "", func(ctx context.Context, predictions []string) ([]float64, error) {
+		scores := make([]float64, len(predictions))
+		for i, prediction := range predictions {
+			scores[i] = float64(len(prediction))
+		}
+		return scores, nil
+	})
+
+	duplicate := NewCompositePairwiseMetric("quality", "", WeightedMeanCombiner,
+		WeightedPairwiseMetric{Metric: length.ToPairwise(DifferenceScore), Weight: 1},
+		WeightedPairwiseMetric{Metric: length.ToPairwise(RatioScore), Weight: 1},
+	)
+	if _, err := duplicate.Compute(context.Background(), []string{"a"}, []string{"b"}); err == nil || !strings.Contains(err.Error(), "length") {
+		t.Errorf("Compute error = %v, want the children named length", err)
+	}
+	evaluation := NewPairwiseEvaluation("duplicate", "", []PairwiseMetric{duplicate})
+	if _, err := evaluation.Run(context.Background(), []Instance{{Reference: "a", Prediction: "b"}}); err == nil {
+		t.Error("Run accepted children with the same name")
+	}
+
+	unnamed := NewCompositePointwiseMetric("quality", "", WeightedMeanCombiner,
+		WeightedPointwiseMetric{Metric: length, Weight: 1},
+		WeightedPointwiseMetric{Metric: NewPointwiseMetric("", "", length.compute), Weight: 1},
+	)
+	if _, err := unnamed.Compute(context.Background(), []string{"a"}); err == nil || !strings.Contains(err.Error(), "child 1") {
+		t.Errorf("Compute error = %v, want the child without a name", err)
+	}
+}
+
+func TestCompositeChildAggregators(t *testing.T) {
+	composite := NewCompositePairwiseMetric("quality", "", WeightedMeanCombiner,
+		WeightedPairwiseMetric{Metric: constantPairwise("high", 1).WithAggregator(SumAggregator), Weight: 1},
+		WeightedPairwiseMetric{Metric: constantPairwise("low", 0.5), Weight: 1},
+	).WithAggregator(MinAggregator)
+
+	evaluation := NewPairwiseEvaluation("quality", "", []PairwiseMetric{composite})
+	aggregators := evaluation.Aggregators()
+	for key, want := range map[string]string{"quality": "min", "quality.high": "sum", "quality.low": ""} {
+		if got := aggregators[key].Name; got != want {
+			t.Errorf("aggregator of %s = %q, want %q", key, got, want)
+		}
+	}
+
+	results, err := evaluation.Run(context.Background(), []Instance{{Prediction: "a"}, {Prediction: "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := evaluation.Summarize(results)
+	if high, _ := summary.Metric("quality.high"); high.Value != 2 {
+		t.Errorf("summary of quality.high = %+v, want a sum of 2", high)
+	}
+	if low, _ := summary.Metric("quality.low"); low.Aggregator != "mean" || low.Value != 0.5 {
+		t.Errorf("summary of quality.low = %+v, want a mean of 0.5", low)
+	}
+}
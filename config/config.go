@@ -0,0 +1,378 @@
+// Package config parses declarative evaluation definitions from YAML or JSON files.
+//
+// A definition names the evaluation and lists its metrics along with their parameters:
+//
+//	name: quotes
+//	description: Quoting behaviour of the new prompt
+//	mode: pairwise
+//	concurrency: 4
+//	metrics:
+//	  - name: word_overlap
+//	  - name: short_quotes_count
+//	    params:
+//	      threshold: 5
+//	    score: ratio
+//	  - name: keyword_presence
+//	    params:
+//	      keywords: [urgent, deadline]
+//	    aggregator: rate
+//	assertions:
+//	  - word_overlap.mean >= 0.5
+//	  - short_quotes_count.p95 <= baseline+1
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	eval "github.com/snpu/eval-go"
+	"gopkg.in/yaml.v3"
+)
+
+// Evaluation modes
+const (
+	Pairwise  = "pairwise"
+	Pointwise = "pointwise"
+)
+
+// Evaluation describes an evaluation and the metrics it computes
+type Evaluation struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Mode is either "pairwise" or "pointwise", defaults to "pairwise"
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Concurrency is the maximum number of metric computations run at once
+	Concurrency int `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+	// ShardSize is the number of instances per metric computation, 0 disables sharding
+	ShardSize int `json:"shard_size,omitempty" yaml:"shard_size,omitempty"`
+	// PartialFailures records failing metrics in the results instead of aborting the run
+	PartialFailures bool     `json:"partial_failures,omitempty" yaml:"partial_failures,omitempty"`
+	Metrics         []Metric `json:"metrics" yaml:"metrics"`
+	// Assertions gate the run on its summary, written as accepted by eval.ParseAssertion,
+	// e.g. "quotes_presence.mean >= 0.9" or "word_overlap >= baseline-0.02"
+	Assertions []string `json:"assertions,omitempty" yaml:"assertions,omitempty"`
+	// Registry holds the metrics available to the definition, nil means eval.DefaultRegistry
+	Registry *eval.Registry `json:"-" yaml:"-"`
+}
+
+// Metric selects a metric by name along with its parameters
+type Metric struct {
+	Name string `json:"name" yaml:"name"`
+	// Params holds the parameters of the metric, e.g. the threshold of short_quotes_count
+	Params map[string]any `json:"params,omitempty" yaml:"params,omitempty"`
+	// Score names the function converting a pointwise metric to pairwise in pairwise evaluations,
+	// one of difference, ratio, absolute_difference, max, min or average, defaults to difference
+	Score string `json:"score,omitempty" yaml:"score,omitempty"`
+	// Aggregator names the aggregator summarizing the metric, defaults to mean
+	Aggregator string `json:"aggregator,omitempty" yaml:"aggregator,omitempty"`
+	// ReferenceReducer names the aggregator reducing the scores of a prediction against multiple references, defaults to max
+	ReferenceReducer string `json:"reference_reducer,omitempty" yaml:"reference_reducer,omitempty"`
+}
+
+// Error reports an invalid value of a definition
+type Error struct {
+	// Path locates the offending value, e.g. "metrics[1].params.threshold"
+	Path string
+	Err  error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Parser parses definitions whose metrics are looked up in Registry
+type Parser struct {
+	// Registry holds the metrics available to definitions, nil means eval.DefaultRegistry
+	Registry *eval.Registry
+}
+
+// Load reads and validates a definition file against eval.DefaultRegistry, see Parser.Load
+func Load(path string) (*Evaluation, error) {
+	return Parser{}.Load(path)
+}
+
+// ParseYAML parses and validates a YAML definition against eval.DefaultRegistry, see Parser.ParseYAML
+func ParseYAML(data []byte) (*Evaluation, error) {
+	return Parser{}.ParseYAML(data)
+}
+
+// ParseJSON parses and validates a JSON definition against eval.DefaultRegistry, see Parser.ParseJSON
+func ParseJSON(data []byte) (*Evaluation, error) {
+	return Parser{}.ParseJSON(data)
+}
+
+// Load reads and validates a definition file, choosing the format from its extension:
+// ".yaml" and ".yml" for YAML and ".json" for JSON
+func (p Parser) Load(path string) (*Evaluation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var definition *Evaluation
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		definition, err = p.ParseYAML(data)
+	case ".json":
+		definition, err = p.ParseJSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return definition, nil
+}
+
+// ParseYAML parses and validates a YAML definition, rejecting unknown fields
+func (p Parser) ParseYAML(data []byte) (*Evaluation, error) {
+	definition := Evaluation{Registry: p.Registry}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&definition); err != nil {
+		return nil, err
+	}
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// ParseJSON parses and validates a JSON definition, rejecting unknown fields
+func (p Parser) ParseJSON(data []byte) (*Evaluation, error) {
+	definition := Evaluation{Registry: p.Registry}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&definition); err != nil {
+		return nil, err
+	}
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// Validate returns an error for the first invalid value of the definition,
+// instantiating every metric in the definition's mode
+func (d *Evaluation) Validate() error {
+	if strings.TrimSpace(d.Name) == "" {
+		return &Error{"name", errors.New("missing evaluation name")}
+	}
+	if d.Concurrency < 0 {
+		return &Error{"concurrency", fmt.Errorf("must not be negative, got %d", d.Concurrency)}
+	}
+	if d.ShardSize < 0 {
+		return &Error{"shard_size", fmt.Errorf("must not be negative, got %d", d.ShardSize)}
+	}
+
+	if _, err := d.ParseAssertions(); err != nil {
+		return err
+	}
+
+	switch d.Mode {
+	case "", Pairwise:
+		_, err := d.Pairwise()
+		return err
+	case Pointwise:
+		_, err := d.Pointwise()
+		return err
+	}
+	return &Error{"mode", fmt.Errorf("unknown mode %q, expected %s or %s", d.Mode, Pairwise, Pointwise)}
+}
+
+// ParseAssertions parses the assertions of the definition
+func (d *Evaluation) ParseAssertions() ([]eval.Assertion, error) {
+	assertions := make([]eval.Assertion, len(d.Assertions))
+	for i, text := range d.Assertions {
+		assertion, err := eval.ParseAssertion(text)
+		if err != nil {
+			return nil, &Error{fmt.Sprintf("assertions[%d]", i), err}
+		}
+		assertions[i] = assertion
+	}
+	return assertions, nil
+}
+
+func (d *Evaluation) registry() *eval.Registry {
+	if d.Registry == nil {
+		return eval.DefaultRegistry
+	}
+	return d.Registry
+}
+
+// Pairwise creates the pairwise evaluation of the definition,
+// converting pointwise metrics with the score function named by their Score
+func (d *Evaluation) Pairwise() (*eval.PairwiseEvaluation, error) {
+	if len(d.Metrics) == 0 {
+		return nil, &Error{"metrics", errors.New("at least one metric is required")}
+	}
+
+	metrics := make([]eval.PairwiseMetric, len(d.Metrics))
+	keys := make(map[string]int)
+	for i, metric := range d.Metrics {
+		var err error
+		if metrics[i], err = metric.pairwise(d.registry(), fmt.Sprintf("metrics[%d]", i)); err != nil {
+			return nil, err
+		}
+		if err := checkResultKeys(keys, i, metrics[i].ResultKeys()); err != nil {
+			return nil, err
+		}
+	}
+
+	evaluation := eval.NewPairwiseEvaluation(d.Name, d.Description, metrics)
+	evaluation.Concurrency = d.Concurrency
+	evaluation.ShardSize = d.ShardSize
+	evaluation.PartialFailures = d.PartialFailures
+	return evaluation, nil
+}
+
+// Pointwise creates the pointwise evaluation of the definition, the Score and ReferenceReducer of its metrics are ignored
+func (d *Evaluation) Pointwise() (*eval.PointwiseEvaluation, error) {
+	if len(d.Metrics) == 0 {
+		return nil, &Error{"metrics", errors.New("at least one metric is required")}
+	}
+
+	metrics := make([]eval.PointwiseMetric, len(d.Metrics))
+	keys := make(map[string]int)
+	for i, metric := range d.Metrics {
+		var err error
+		if metrics[i], err = metric.pointwise(d.registry(), fmt.Sprintf("metrics[%d]", i)); err != nil {
+			return nil, err
+		}
+		if err := checkResultKeys(keys, i, metrics[i].ResultKeys()); err != nil {
+			return nil, err
+		}
+	}
+
+	evaluation := eval.NewPointwiseEvaluation(d.Name, d.Description, metrics)
+	evaluation.Concurrency = d.Concurrency
+	evaluation.ShardSize = d.ShardSize
+	evaluation.PartialFailures = d.PartialFailures
+	return evaluation, nil
+}
+
+// checkResultKeys records the result keys of the metric at index i in keys, returning an error for a key
+// already computed by an earlier metric, whose results would otherwise be overwritten
+func checkResultKeys(keys map[string]int, i int, metricKeys []string) error {
+	for _, key := range metricKeys {
+		if first, ok := keys[key]; ok {
+			return &Error{fmt.Sprintf("metrics[%d].name", i), fmt.Errorf("result key %q is already computed by metrics[%d]", key, first)}
+		}
+		keys[key] = i
+	}
+	return nil
+}
+
+// pairwise creates the pairwise metric m selects, path locates m in the definition
+func (m Metric) pairwise(registry *eval.Registry, path string) (eval.PairwiseMetric, error) {
+	factory, params, err := m.factory(registry, path)
+	if err != nil {
+		return eval.PairwiseMetric{}, err
+	}
+
+	var metric eval.PairwiseMetric
+	if factory.Pairwise() {
+		if m.Score != "" {
+			return eval.PairwiseMetric{}, &Error{path + ".score", fmt.Errorf("metric %s compares references and predictions and takes no score function", m.Name)}
+		}
+		if metric, err = factory.NewPairwise(params); err != nil {
+			return eval.PairwiseMetric{}, &Error{path + ".params", err}
+		}
+	} else {
+		scoreName := m.Score
+		if scoreName == "" {
+			scoreName = "difference"
+		}
+		scoreFunc, ok := scoreFuncs[scoreName]
+		if !ok {
+			return eval.PairwiseMetric{}, &Error{path + ".score", fmt.Errorf("unknown score function %q", scoreName)}
+		}
+
+		pointwise, err := factory.NewPointwise(params)
+		if err != nil {
+			return eval.PairwiseMetric{}, &Error{path + ".params", err}
+		}
+		metric = pointwise.ToPairwise(scoreFunc)
+	}
+
+	if m.Aggregator != "" {
+		aggregator, err := aggregatorParam(path+".aggregator", m.Aggregator)
+		if err != nil {
+			return eval.PairwiseMetric{}, err
+		}
+		metric = metric.WithAggregator(aggregator)
+	}
+	if m.ReferenceReducer != "" {
+		reducer, err := aggregatorParam(path+".reference_reducer", m.ReferenceReducer)
+		if err != nil {
+			return eval.PairwiseMetric{}, err
+		}
+		metric.ReferenceReducer = reducer
+	}
+	return metric, nil
+}
+
+// pointwise creates the pointwise metric m selects, path locates m in the definition
+func (m Metric) pointwise(registry *eval.Registry, path string) (eval.PointwiseMetric, error) {
+	factory, params, err := m.factory(registry, path)
+	if err != nil {
+		return eval.PointwiseMetric{}, err
+	}
+	if factory.Pairwise() {
+		return eval.PointwiseMetric{}, &Error{path + ".name", fmt.Errorf("metric %s compares references and predictions and cannot run in pointwise mode", m.Name)}
+	}
+
+	metric, err := factory.NewPointwise(params)
+	if err != nil {
+		return eval.PointwiseMetric{}, &Error{path + ".params", err}
+	}
+	if m.Aggregator != "" {
+		aggregator, err := aggregatorParam(path+".aggregator", m.Aggregator)
+		if err != nil {
+			return eval.PointwiseMetric{}, err
+		}
+		metric = metric.WithAggregator(aggregator)
+	}
+	return metric, nil
+}
+
+// factory looks up the factory of m in registry and validates the parameters of m against it
+func (m Metric) factory(registry *eval.Registry, path string) (eval.MetricFactory, eval.Params, error) {
+	if strings.TrimSpace(m.Name) == "" {
+		return eval.MetricFactory{}, nil, &Error{path + ".name", errors.New("missing metric name")}
+	}
+	factory, ok := registry.Lookup(m.Name)
+	if !ok {
+		return eval.MetricFactory{}, nil, &Error{path + ".name", fmt.Errorf("unknown metric %q", m.Name)}
+	}
+
+	params, err := factory.ParseParams(m.Params)
+	if err != nil {
+		var paramErr *eval.ParamError
+		if errors.As(err, &paramErr) {
+			return eval.MetricFactory{}, nil, &Error{path + ".params." + paramErr.Param, paramErr.Err}
+		}
+		return eval.MetricFactory{}, nil, &Error{path + ".params", err}
+	}
+	return factory, params, nil
+}
+
+// aggregatorParam looks up the built-in aggregator named by an aggregator or reference reducer field
+func aggregatorParam(path, name string) (eval.Aggregator, error) {
+	aggregator, ok := eval.LookupAggregator(name)
+	if !ok {
+		return eval.Aggregator{}, &Error{path, fmt.Errorf("unknown aggregator %q", name)}
+	}
+	return aggregator, nil
+}
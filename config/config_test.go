@@ -0,0 +1,159 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	eval "github.com/snpu/eval-go"
+)
+
+const testYAML = `
+name: keywords
+description: Keyword coverage
+concurrency: 2
+shard_size: 10
+partial_failures: true
+metrics:
+  - name: keyword_presence
+    params:
+      keywords: [urgent, deadline]
+    score: absolute_difference
+    aggregator: max
+  - name: word_overlap
+    reference_reducer: min
+assertions:
+  - keyword_presence <= 1
+`
+
+func TestParseYAML(t *testing.T) {
+	definition, err := ParseYAML([]byte(testYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	evaluation, err := definition.Pairwise()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evaluation.Name != "keywords" || evaluation.Description != "Keyword coverage" ||
+		evaluation.Concurrency != 2 || evaluation.ShardSize != 10 || !evaluation.PartialFailures {
+		t.Errorf("evaluation = %+v", evaluation)
+	}
+	aggregators := evaluation.Aggregators()
+	// Metrics without an aggregator keep the zero aggregator, summarized as a mean
+	if aggregators["keyword_presence"].Name != "max" || aggregators["word_overlap"].Name != "" {
+		t.Errorf("aggregators = %v", aggregators)
+	}
+
+	results, err := evaluation.Run(context.Background(), []eval.Instance{
+		{Reference: "urgent and deadline", Prediction: "urgent only"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The keywords parameter and the score function both apply: |0.5 - 1|
+	if got := results[0].MetricResults["keyword_presence"]; got != 0.5 {
+		t.Errorf("keyword_presence = %g, want 0.5", got)
+	}
+
+	assertions, err := definition.ParseAssertions()
+	if err != nil || len(assertions) != 1 {
+		t.Errorf("assertions = %v, %v", assertions, err)
+	}
+}
+
+func TestParseJSONPointwise(t *testing.T) {
+	definition, err := ParseJSON([]byte(`{"name": "p", "mode": "pointwise", "metrics": [{"name": "keyword_presence", "params": {"keywords": ["a"]}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	evaluation, err := definition.Pointwise()
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := evaluation.Run(context.Background(), []string{"a b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].MetricResults["keyword_presence"] != 1 || results[1].MetricResults["keyword_presence"] != 0 {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		path       string
+	}{
+		{"missing name", `{"metrics": [{"name": "word_overlap"}]}`, "name"},
+		{"negative concurrency", `{"name": "x", "concurrency": -1, "metrics": [{"name": "word_overlap"}]}`, "concurrency"},
+		{"negative shard size", `{"name": "x", "shard_size": -1, "metrics": [{"name": "word_overlap"}]}`, "shard_size"},
+		{"unknown mode", `{"name": "x", "mode": "listwise", "metrics": [{"name": "word_overlap"}]}`, "mode"},
+		{"no metrics", `{"name": "x"}`, "metrics"},
+		{"unknown metric", `{"name": "x", "metrics": [{"name": "word_overlap"}, {"name": "nope"}]}`, "metrics[1].name"},
+		{"missing metric name", `{"name": "x", "metrics": [{"params": {}}]}`, "metrics[0].name"},
+		{"bad param", `{"name": "x", "metrics": [{"name": "short_quotes_count", "params": {"threshold": "five"}}]}`, "metrics[0].params.threshold"},
+		{"unknown score", `{"name": "x", "metrics": [{"name": "quotes_count", "score": "product"}]}`, "metrics[0].score"},
+		{"score on pairwise metric", `{"name": "x", "metrics": [{"name": "word_overlap", "score": "ratio"}]}`, "metrics[0].score"},
+		{"unknown aggregator", `{"name": "x", "metrics": [{"name": "word_overlap", "aggregator": "mode"}]}`, "metrics[0].aggregator"},
+		{"unknown reducer", `{"name": "x", "metrics": [{"name": "word_overlap", "reference_reducer": "mode"}]}`, "metrics[0].reference_reducer"},
+		{"pairwise metric in pointwise mode", `{"name": "x", "mode": "pointwise", "metrics": [{"name": "word_overlap"}]}`, "metrics[0].name"},
+		{"duplicate metric", `{"name": "x", "metrics": [{"name": "word_overlap"}, {"name": "length_ratio"}, {"name": "word_overlap", "aggregator": "max"}]}`, "metrics[2].name"},
+		{"duplicate pointwise metric", `{"name": "x", "mode": "pointwise", "metrics": [{"name": "short_quotes_count", "params": {"threshold": 3}}, {"name": "short_quotes_count"}]}`, "metrics[1].name"},
+		{"bad assertion", `{"name": "x", "metrics": [{"name": "word_overlap"}], "assertions": ["word_overlap ~ 1"]}`, "assertions[0]"},
+	}
+	for _, tt := range tests {
+		_, err := ParseJSON([]byte(tt.definition))
+		var configErr *Error
+		if !errors.As(err, &configErr) || configErr.Path != tt.path {
+			t.Errorf("%s: error = %v, want one at %s", tt.name, err, tt.path)
+		}
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	if _, err := ParseYAML([]byte("name: x\nmetric:\n  - name: word_overlap\n")); err == nil {
+		t.Error("ParseYAML accepted an unknown field")
+	}
+	if _, err := ParseJSON([]byte(`{"name": "x", "metrics": [{"name": "word_overlap", "param": {}}]}`)); err == nil {
+		t.Error("ParseJSON accepted an unknown field")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"eval.yaml": testYAML,
+		"eval.json": `{"name": "keywords", "metrics": [{"name": "word_overlap"}]}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		definition, err := Load(path)
+		if err != nil || definition.Name != "keywords" {
+			t.Errorf("%s: definition = %+v, %v", name, definition, err)
+		}
+	}
+
+	path := filepath.Join(dir, "eval.toml")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("error = %v, want an unsupported format", err)
+	}
+}
+
+func TestParserRegistry(t *testing.T) {
+	registry := eval.NewRegistry()
+	_, err := Parser{Registry: registry}.ParseJSON([]byte(`{"name": "x", "metrics": [{"name": "word_overlap"}]}`))
+	var configErr *Error
+	if !errors.As(err, &configErr) || configErr.Path != "metrics[0].name" {
+		t.Errorf("error = %v, want word_overlap unknown to an empty registry", err)
+	}
+}
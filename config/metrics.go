@@ -0,0 +1,17 @@
+package config
+
+import (
+	eval "github.com/snpu/eval-go"
+	// Register the built-in metrics
+	_ "github.com/snpu/eval-go/metrics"
+)
+
+// scoreFuncs maps score function names to the functions converting pointwise metrics to pairwise
+var scoreFuncs = map[string]eval.PairwiseScoreFunc{
+	"difference":          eval.DifferenceScore,
+	"ratio":               eval.RatioScore,
+	"absolute_difference": eval.AbsoluteDifferenceScore,
+	"max":                 eval.MaxScore,
+	"min":                 eval.MinScore,
+	"average":             eval.AverageScore,
+}
@@ -0,0 +1,31 @@
+package eval
+
+import "context"
+
+type instancesKey struct{}
+
+type pointwiseInstancesKey struct{}
+
+// InstancesFromContext returns the instances a pairwise metric is being computed on, in the same
+// order as its references and predictions. It lets metric functions read instance IDs, metadata and tags.
+func InstancesFromContext(ctx context.Context) ([]Instance, bool) {
+	instances, ok := ctx.Value(instancesKey{}).([]Instance)
+	return instances, ok
+}
+
+// PointwiseInstancesFromContext returns the instances a pointwise metric is being computed on, in the
+// same order as its predictions. It lets metric functions read instance IDs, metadata and tags.
+func PointwiseInstancesFromContext(ctx context.Context) ([]PointwiseInstance, bool) {
+	instances, ok := ctx.Value(pointwiseInstancesKey{}).([]PointwiseInstance)
+	return instances, ok
+}
+
+// withInstances returns a copy of ctx carrying the instances of a pairwise batch
+func withInstances(ctx context.Context, instances []Instance) context.Context {
+	return context.WithValue(ctx, instancesKey{}, instances)
+}
+
+// withPointwiseInstances returns a copy of ctx carrying the instances of a pointwise batch
+func withPointwiseInstances(ctx context.Context, instances []PointwiseInstance) context.Context {
+	return context.WithValue(ctx, pointwiseInstancesKey{}, instances)
+}
@@ -0,0 +1,113 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// lengthMetadata scores every instance with the "length" in its metadata, read from the context
+func lengthMetadata(ctx context.Context, n int) ([]float64, error) {
+	var metadata []map[string]string
+	if instances, ok := InstancesFromContext(ctx); ok {
+		for _, instance := range instances {
+			metadata = append(metadata, instance.Metadata)
+		}
+	} else if instances, ok := PointwiseInstancesFromContext(ctx); ok {
+		for _, instance := range instances {
+			metadata = append(metadata, instance.Metadata)
+		}
+	} else {
+		return nil, fmt.Errorf("no instances in context")
+	}
+	if len(metadata) != n {
+		return nil, fmt.Errorf("%d instances in context, want %d", len(metadata), n)
+	}
+
+	scores := make([]float64, n)
+	for i, m := range metadata {
+		length, err := strconv.Atoi(m["length"])
+		if err != nil {
+			return nil, err
+		}
+		scores[i] = float64(length)
+	}
+	return scores, nil
+}
+
+func TestInstancesFromContext(t *testing.T) {
+	metric := NewPairwiseMetric("length", "", func(ctx context.Context, references, predictions []string) ([]float64, error) {
+		return lengthMetadata(ctx, len(predictions))
+	})
+	instances := make([]Instance, 5)
+	for i := range instances {
+		instances[i] = Instance{
+			ID:         fmt.Sprintf("row-%d", i),
+			Prediction: "p",
+			Metadata:   map[string]string{"length": strconv.Itoa(i * 10)},
+			Tags:       []string{"tag"},
+		}
+	}
+
+	evaluation := NewPairwiseEvaluation("context", "", []PairwiseMetric{metric})
+	evaluation.ShardSize = 2
+	results, err := evaluation.Run(context.Background(), instances)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every shard sees its own instances, aligned with its predictions
+	for i, result := range results {
+		if result.Instance.ID != instances[i].ID || len(result.Instance.Tags) != 1 {
+			t.Errorf("result %d carries instance %+v, want %+v", i, result.Instance, instances[i])
+		}
+		if got, want := result.MetricResults["length"], float64(i*10); got != want {
+			t.Errorf("result %d scored %g, want %g", i, got, want)
+		}
+	}
+}
+
+func TestPointwiseInstancesFromContext(t *testing.T) {
+	metric := NewPointwiseMetric("length", "", func(ctx context.Context, predictions []string) ([]float64, error) {
+		return lengthMetadata(ctx, len(predictions))
+	})
+	instances := PointwiseInstances([]Instance{
+		{ID: "a", Reference: "r", Prediction: "x", Metadata: map[string]string{"length": "3"}, Tags: []string{"en"}},
+		{ID: "b", Reference: "r", Prediction: "y", Metadata: map[string]string{"length": "7"}},
+	})
+
+	evaluation := NewPointwiseEvaluation("context", "", []PointwiseMetric{metric})
+	evaluation.ShardSize = 1
+	results, err := evaluation.RunInstances(context.Background(), instances)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Instance.ID != "a" || results[0].Instance.Prediction != "x" || results[0].Instance.Tags[0] != "en" {
+		t.Errorf("first result carries instance %+v", results[0].Instance)
+	}
+	if results[0].MetricResults["length"] != 3 || results[1].MetricResults["length"] != 7 {
+		t.Errorf("scores = %v, %v, want 3 and 7", results[0].MetricResults, results[1].MetricResults)
+	}
+}
+
+func TestRunDuplicateIDs(t *testing.T) {
+	pairwise := NewPairwiseEvaluation("ids", "", []PairwiseMetric{constantPairwise("constant", 1)})
+	_, err := pairwise.Run(context.Background(), []Instance{{ID: "a"}, {}, {}, {ID: "b"}, {ID: "a"}})
+	if err == nil || !strings.Contains(err.Error(), `"a"`) {
+		t.Errorf("pairwise Run error = %v, want the duplicate ID a", err)
+	}
+
+	pointwise := NewPointwiseEvaluation("ids", "", []PointwiseMetric{
+		NewPointwiseMetric("constant", "", func(ctx context.Context, predictions []string) ([]float64, error) {
+			return make([]float64, len(predictions)), nil
+		}),
+	})
+	_, err = pointwise.RunInstances(context.Background(), []PointwiseInstance{{ID: "b"}, {ID: "b"}})
+	if err == nil || !strings.Contains(err.Error(), `"b"`) {
+		t.Errorf("pointwise Run error = %v, want the duplicate ID b", err)
+	}
+	if _, err := pointwise.RunInstances(context.Background(), []PointwiseInstance{{ID: "a"}, {}, {}}); err != nil {
+		t.Errorf("Run with unique and missing IDs failed: %v", err)
+	}
+}
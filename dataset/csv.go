@@ -0,0 +1,122 @@
+package dataset
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// CSVReader streams instances from a CSV file whose first row names the columns.
+// The references and tags columns hold lists separated by the mapping's ListSeparator.
+type CSVReader struct {
+	// Comma is the field delimiter, it may be changed before the first call to Read
+	Comma rune
+
+	reader  *csv.Reader
+	mapping FieldMapping
+	columns []string
+}
+
+// NewCSVReader creates a reader streaming instances from r
+func NewCSVReader(r io.Reader, mapping FieldMapping) *CSVReader {
+	return &CSVReader{
+		Comma:   ',',
+		reader:  csv.NewReader(r),
+		mapping: mapping.withDefaults(),
+	}
+}
+
+// Read returns the next instance, or io.EOF once every row has been read
+func (r *CSVReader) Read() (eval.Instance, error) {
+	if r.columns == nil {
+		if err := r.readHeader(); err != nil {
+			return eval.Instance{}, err
+		}
+	}
+
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return eval.Instance{}, io.EOF
+	}
+	if err != nil {
+		// csv.ParseError already reports its line
+		return eval.Instance{}, err
+	}
+
+	m := r.mapping
+	var instance eval.Instance
+	for i, value := range record {
+		switch column := r.columns[i]; {
+		case column == m.ID:
+			instance.ID = value
+		case column == m.Reference:
+			instance.Reference = value
+		case column == m.References:
+			instance.References = splitList(value, m.ListSeparator)
+		case column == m.Prediction:
+			instance.Prediction = value
+		case column == m.Tags:
+			instance.Tags = splitList(value, m.ListSeparator)
+		case includesMetadata(m, column):
+			if instance.Metadata == nil {
+				instance.Metadata = make(map[string]string)
+			}
+			instance.Metadata[column] = value
+		}
+	}
+
+	return instance, nil
+}
+
+// ReadCSV reads every instance of a CSV stream
+func ReadCSV(r io.Reader, mapping FieldMapping) ([]eval.Instance, error) {
+	return ReadAll(NewCSVReader(r, mapping))
+}
+
+func (r *CSVReader) readHeader() error {
+	r.reader.Comma = r.Comma
+
+	header, err := r.reader.Read()
+	if err == io.EOF {
+		return &ParseError{Line: 1, Err: fmt.Errorf("missing header row")}
+	}
+	if err != nil {
+		return err
+	}
+
+	// Spreadsheet exports often start with a UTF-8 byte order mark, which is not part of the first column name
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if seen[column] {
+			return &ParseError{Line: 1, Field: column, Err: fmt.Errorf("duplicate column")}
+		}
+		seen[column] = true
+		header[i] = column
+	}
+	if !seen[r.mapping.Prediction] {
+		return &ParseError{Line: 1, Field: r.mapping.Prediction, Err: fmt.Errorf("missing column")}
+	}
+
+	r.columns = header
+	return nil
+}
+
+// splitList splits a list column, an empty column is an empty list
+func splitList(value, separator string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	items := strings.Split(value, separator)
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+	}
+	return items
+}
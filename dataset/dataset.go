@@ -0,0 +1,135 @@
+// Package dataset loads evaluation instances from JSONL and CSV files
+package dataset
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// FieldMapping names the fields of a dataset row that make up an instance.
+// Empty names fall back to the default field names.
+type FieldMapping struct {
+	// ID is the field holding the instance ID, defaults to "id"
+	ID string
+	// Reference is the field holding the reference, defaults to "reference"
+	Reference string
+	// References is the field holding further references, defaults to "references"
+	References string
+	// Prediction is the field holding the prediction, defaults to "prediction"
+	Prediction string
+	// Tags is the field holding the instance tags, defaults to "tags"
+	Tags string
+	// Metadata lists the fields copied into the instance metadata, nil copies every unmapped field
+	Metadata []string
+	// ListSeparator splits the references and tags columns of CSV files, defaults to "|"
+	ListSeparator string
+}
+
+// withDefaults returns a copy of the mapping with empty field names replaced by their defaults
+func (m FieldMapping) withDefaults() FieldMapping {
+	if m.ID == "" {
+		m.ID = "id"
+	}
+	if m.Reference == "" {
+		m.Reference = "reference"
+	}
+	if m.References == "" {
+		m.References = "references"
+	}
+	if m.Prediction == "" {
+		m.Prediction = "prediction"
+	}
+	if m.Tags == "" {
+		m.Tags = "tags"
+	}
+	if m.ListSeparator == "" {
+		m.ListSeparator = "|"
+	}
+	return m
+}
+
+// isMapped reports whether field is mapped to an instance field other than metadata
+func (m FieldMapping) isMapped(field string) bool {
+	switch field {
+	case m.ID, m.Reference, m.References, m.Prediction, m.Tags:
+		return true
+	}
+	return false
+}
+
+// ParseError reports a malformed dataset row
+type ParseError struct {
+	// Line is the 1-based line of the row in the file
+	Line int
+	// Field is the offending field, empty when the whole row is malformed
+	Field string
+	Err   error
+}
+
+// Error implements the error interface
+func (e *ParseError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d: field %q: %v", e.Line, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads instances one at a time, returning io.EOF once the dataset is exhausted
+type Reader interface {
+	Read() (eval.Instance, error)
+}
+
+// ReadAll reads every remaining instance of r
+func ReadAll(r Reader) ([]eval.Instance, error) {
+	var instances []eval.Instance
+	for {
+		instance, err := r.Read()
+		if err == io.EOF {
+			return instances, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, instance)
+	}
+}
+
+// Load reads every instance of a dataset file, choosing the format from its extension:
+// ".jsonl" and ".ndjson" for JSONL, ".csv" for CSV and ".tsv" for tab-separated values
+func Load(path string, mapping FieldMapping) ([]eval.Instance, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader Reader
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".jsonl", ".ndjson":
+		reader = NewJSONLReader(file, mapping)
+	case ".csv":
+		reader = NewCSVReader(file, mapping)
+	case ".tsv":
+		csvReader := NewCSVReader(file, mapping)
+		csvReader.Comma = '\t'
+		reader = csvReader
+	default:
+		return nil, fmt.Errorf("unsupported dataset format %q", ext)
+	}
+
+	instances, err := ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return instances, nil
+}
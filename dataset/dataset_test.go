@@ -0,0 +1,155 @@
+package dataset
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	eval "github.com/snpu/eval-go"
+)
+
+func TestReadJSONL(t *testing.T) {
+	input := `{"id": 1, "reference": "a cat", "prediction": "the cat", "tags": ["en", "qa"], "source": "web", "score": 0.5}
+
+{"id": "b", "references": "one", "prediction": "two", "tags": "fr", "extra": {"x": [1, 2]}}
+`
+	instances, err := ReadJSONL(strings.NewReader(input), FieldMapping{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []eval.Instance{
+		{ID: "1", Reference: "a cat", Prediction: "the cat", Tags: []string{"en", "qa"},
+			Metadata: map[string]string{"source": "web", "score": "0.5"}},
+		{ID: "b", References: []string{"one"}, Prediction: "two", Tags: []string{"fr"},
+			Metadata: map[string]string{"extra": `{"x":[1,2]}`}},
+	}
+	if !reflect.DeepEqual(instances, want) {
+		t.Errorf("instances = %+v, want %+v", instances, want)
+	}
+}
+
+func TestReadJSONLMapping(t *testing.T) {
+	input := `{"qid": "q1", "answer": "yes", "output": "no", "lang": "en", "ignored": "x"}`
+	mapping := FieldMapping{ID: "qid", Reference: "answer", Prediction: "output", Metadata: []string{"lang"}}
+	instances, err := ReadJSONL(strings.NewReader(input), mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []eval.Instance{{ID: "q1", Reference: "yes", Prediction: "no", Metadata: map[string]string{"lang": "en"}}}
+	if !reflect.DeepEqual(instances, want) {
+		t.Errorf("instances = %+v, want %+v", instances, want)
+	}
+}
+
+func TestReadJSONLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+		field string
+	}{
+		{"invalid JSON", "{\"prediction\": \"a\"}\n{oops}\n", 2, ""},
+		{"missing prediction", "\n\n{\"reference\": \"a\"}\n", 3, "prediction"},
+		{"object reference", `{"prediction": "a", "reference": {}}`, 1, "reference"},
+		{"number tags", `{"prediction": "a", "tags": [1]}`, 1, "tags"},
+		{"two objects", "{\"prediction\": \"a\"}\n{\"prediction\": \"b\"} {\"prediction\": \"c\"}\n", 2, ""},
+		{"trailing brace", `{"prediction": "a"}}`, 1, ""},
+		{"trailing value", `{"prediction": "a"} 1`, 1, ""},
+	}
+	for _, tt := range tests {
+		_, err := ReadJSONL(strings.NewReader(tt.input), FieldMapping{})
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Line != tt.line || parseErr.Field != tt.field {
+			t.Errorf("%s: error = %v, want a parse error at line %d, field %q", tt.name, err, tt.line, tt.field)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	input := "id, reference ,prediction,tags,references,lang\n" +
+		"1,a cat,the cat,en | qa,,en\n" +
+		"2,,two,,one|uno,es\n"
+	instances, err := ReadCSV(strings.NewReader(input), FieldMapping{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []eval.Instance{
+		{ID: "1", Reference: "a cat", Prediction: "the cat", Tags: []string{"en", "qa"}, Metadata: map[string]string{"lang": "en"}},
+		{ID: "2", References: []string{"one", "uno"}, Prediction: "two", Metadata: map[string]string{"lang": "es"}},
+	}
+	if !reflect.DeepEqual(instances, want) {
+		t.Errorf("instances = %+v, want %+v", instances, want)
+	}
+}
+
+func TestReadCSVByteOrderMark(t *testing.T) {
+	instances, err := ReadCSV(strings.NewReader("\ufeffid,prediction\n1,a\n"), FieldMapping{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []eval.Instance{{ID: "1", Prediction: "a"}}; !reflect.DeepEqual(instances, want) {
+		t.Errorf("instances = %+v, want %+v", instances, want)
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		field string
+	}{
+		{"empty", "", ""},
+		{"duplicate column", "prediction,id,id\n", "id"},
+		{"missing prediction", "id,reference\n1,a\n", "prediction"},
+	}
+	for _, tt := range tests {
+		_, err := ReadCSV(strings.NewReader(tt.input), FieldMapping{})
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Line != 1 || parseErr.Field != tt.field {
+			t.Errorf("%s: error = %v, want a parse error on the header, field %q", tt.name, err, tt.field)
+		}
+	}
+
+	// Malformed rows report their line through csv.ParseError
+	_, err := ReadCSV(strings.NewReader("prediction,id\na,1\nb\n"), FieldMapping{})
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("error = %v, want one reporting line 3", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"data.jsonl": `{"id": "1", "prediction": "a"}`,
+		"data.csv":   "id,prediction\n1,a\n",
+		"data.tsv":   "id\tprediction\n1\ta\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		instances, err := Load(path, FieldMapping{})
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(instances) != 1 || instances[0].ID != "1" || instances[0].Prediction != "a" {
+			t.Errorf("%s: instances = %+v", name, instances)
+		}
+	}
+
+	if _, err := Load(filepath.Join(dir, "data.txt"), FieldMapping{}); err == nil {
+		t.Error("Load accepted a missing file")
+	}
+	path := filepath.Join(dir, "data.xml")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, FieldMapping{}); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("error = %v, want an unsupported format", err)
+	}
+}
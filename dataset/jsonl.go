@@ -0,0 +1,163 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// JSONLReader streams instances from a JSONL file holding one JSON object per line.
+// String and number fields are read as text, references and tags may be a string or an array of strings,
+// and metadata fields that are not strings are kept as compact JSON. Blank lines are skipped and a line holding
+// anything after its object is an error.
+type JSONLReader struct {
+	reader  *bufio.Reader
+	mapping FieldMapping
+	line    int
+}
+
+// NewJSONLReader creates a reader streaming instances from r
+func NewJSONLReader(r io.Reader, mapping FieldMapping) *JSONLReader {
+	return &JSONLReader{
+		reader:  bufio.NewReader(r),
+		mapping: mapping.withDefaults(),
+	}
+}
+
+// Read returns the next instance, or io.EOF once every line has been read
+func (r *JSONLReader) Read() (eval.Instance, error) {
+	for {
+		data, err := r.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return eval.Instance{}, err
+		}
+		if len(data) == 0 && err == io.EOF {
+			return eval.Instance{}, io.EOF
+		}
+		r.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			if err == io.EOF {
+				return eval.Instance{}, io.EOF
+			}
+			continue
+		}
+
+		return r.parse(data)
+	}
+}
+
+// ReadJSONL reads every instance of a JSONL stream
+func ReadJSONL(r io.Reader, mapping FieldMapping) ([]eval.Instance, error) {
+	return ReadAll(NewJSONLReader(r, mapping))
+}
+
+func (r *JSONLReader) parse(data []byte) (eval.Instance, error) {
+	var fields map[string]json.RawMessage
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return eval.Instance{}, &ParseError{Line: r.line, Err: fmt.Errorf("invalid JSON object: %w", err)}
+	}
+	// A line holds a single object, anything after it is a missing line break or a stray value
+	if _, err := decoder.Token(); err != io.EOF {
+		return eval.Instance{}, &ParseError{Line: r.line, Err: fmt.Errorf("unexpected data after the JSON object at offset %d", decoder.InputOffset())}
+	}
+
+	m := r.mapping
+	var instance eval.Instance
+	var err error
+
+	if _, ok := fields[m.Prediction]; !ok {
+		return eval.Instance{}, &ParseError{Line: r.line, Field: m.Prediction, Err: fmt.Errorf("missing field")}
+	}
+	if instance.Prediction, err = r.text(fields, m.Prediction); err != nil {
+		return eval.Instance{}, err
+	}
+	if instance.ID, err = r.text(fields, m.ID); err != nil {
+		return eval.Instance{}, err
+	}
+	if instance.Reference, err = r.text(fields, m.Reference); err != nil {
+		return eval.Instance{}, err
+	}
+	if instance.References, err = r.list(fields, m.References); err != nil {
+		return eval.Instance{}, err
+	}
+	if instance.Tags, err = r.list(fields, m.Tags); err != nil {
+		return eval.Instance{}, err
+	}
+
+	for name, value := range fields {
+		if m.isMapped(name) || !includesMetadata(m, name) {
+			continue
+		}
+		if instance.Metadata == nil {
+			instance.Metadata = make(map[string]string)
+		}
+		var text string
+		if err := json.Unmarshal(value, &text); err != nil {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, value); err != nil {
+				return eval.Instance{}, &ParseError{Line: r.line, Field: name, Err: err}
+			}
+			text = compact.String()
+		}
+		instance.Metadata[name] = text
+	}
+
+	return instance, nil
+}
+
+// text reads a string or number field, a missing or null field reads as empty
+func (r *JSONLReader) text(fields map[string]json.RawMessage, name string) (string, error) {
+	value, ok := fields[name]
+	if !ok || string(value) == "null" {
+		return "", nil
+	}
+
+	var text string
+	if err := json.Unmarshal(value, &text); err == nil {
+		return text, nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(value, &number); err == nil {
+		return number.String(), nil
+	}
+	return "", &ParseError{Line: r.line, Field: name, Err: fmt.Errorf("expected a string, got %s", value)}
+}
+
+// list reads a string or array of strings field, a missing or null field reads as nil
+func (r *JSONLReader) list(fields map[string]json.RawMessage, name string) ([]string, error) {
+	value, ok := fields[name]
+	if !ok || string(value) == "null" {
+		return nil, nil
+	}
+
+	var items []string
+	if err := json.Unmarshal(value, &items); err == nil {
+		return items, nil
+	}
+	var item string
+	if err := json.Unmarshal(value, &item); err == nil {
+		return []string{item}, nil
+	}
+	return nil, &ParseError{Line: r.line, Field: name, Err: fmt.Errorf("expected a string or an array of strings, got %s", value)}
+}
+
+// includesMetadata reports whether an unmapped field is copied into the instance metadata
+func includesMetadata(m FieldMapping, name string) bool {
+	if m.Metadata == nil {
+		return true
+	}
+	for _, field := range m.Metadata {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
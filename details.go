@@ -0,0 +1,92 @@
+package eval
+
+import (
+	"context"
+	"sync"
+)
+
+// Details holds structured information explaining the scores of a metric on one instance, e.g. the
+// matched words, the extracted quotes or the rationale of a judge. Values should be JSON encodable.
+type Details map[string]any
+
+// MetricDetails maps metric names to the details they attached to an instance
+type MetricDetails map[string]Details
+
+type detailsKey struct{}
+
+// detailsRecorder collects the details attached to the instances of a batch, keyed by their index in it
+type detailsRecorder struct {
+	mu      sync.Mutex
+	details map[int]Details
+}
+
+// AttachDetails attaches details to the instance at index i of the batch a metric function is computing,
+// merging them with the details already attached to it. Evaluation runs record them in each result's
+// Details under the metric's name. It is safe for concurrent use and does nothing outside of a run.
+func AttachDetails(ctx context.Context, i int, details Details) {
+	recorder, ok := ctx.Value(detailsKey{}).(*detailsRecorder)
+	if !ok || len(details) == 0 {
+		return
+	}
+	recorder.attach(i, details)
+}
+
+func (r *detailsRecorder) attach(i int, details Details) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.details == nil {
+		r.details = make(map[int]Details)
+	}
+	merged := r.details[i]
+	if merged == nil {
+		merged = make(Details, len(details))
+		r.details[i] = merged
+	}
+	for key, value := range details {
+		merged[key] = value
+	}
+}
+
+// collected returns the details attached so far, keyed by instance index
+func (r *detailsRecorder) collected() map[int]Details {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.details
+}
+
+// recordDetails returns a copy of ctx whose attached details are collected by a new recorder
+func recordDetails(ctx context.Context) (context.Context, *detailsRecorder) {
+	recorder := &detailsRecorder{}
+	return context.WithValue(ctx, detailsKey{}, recorder), recorder
+}
+
+// nestDetails collects the details attached while computing a nested batch, e.g. the children of a
+// composite metric, and attaches them to the same instances of the enclosing batch under key
+func nestDetails(ctx context.Context, key string, compute func(ctx context.Context)) {
+	if _, ok := ctx.Value(detailsKey{}).(*detailsRecorder); !ok {
+		compute(ctx)
+		return
+	}
+
+	nestedCtx, recorder := recordDetails(ctx)
+	compute(nestedCtx)
+	for i, details := range recorder.collected() {
+		AttachDetails(ctx, i, Details{key: details})
+	}
+}
+
+// mergeDetails collects the details every metric attached to instance i, keyed by metric name
+func mergeDetails(metrics []string, details [][]Details, i int) MetricDetails {
+	var merged MetricDetails
+	for m, name := range metrics {
+		if details[m][i] == nil {
+			continue
+		}
+		if merged == nil {
+			merged = make(MetricDetails)
+		}
+		merged[name] = details[m][i]
+	}
+	return merged
+}
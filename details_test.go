@@ -0,0 +1,66 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// echoPointwise scores every prediction 1 and attaches the prediction and its index in the batch
+func echoPointwise(name string) PointwiseMetric {
+	return NewPointwiseMetric(name, "", func(ctx context.Context, predictions []string) ([]float64, error) {
+		scores := make([]float64, len(predictions))
+		for i, prediction := range predictions {
+			scores[i] = 1
+			AttachDetails(ctx, i, Details{"text": prediction})
+			AttachDetails(ctx, i, Details{"index": i})
+		}
+		return scores, nil
+	})
+}
+
+func TestAttachDetails(t *testing.T) {
+	evaluation := NewPointwiseEvaluation("details", "", []PointwiseMetric{echoPointwise("echo"), echoPointwise("other")})
+	evaluation.ShardSize = 2
+	evaluation.Concurrency = 2
+	results, err := evaluation.Run(context.Background(), []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Details merge per instance and land on the instance of their shard
+	for i, result := range results {
+		want := fmt.Sprintf("map[echo:map[index:%d text:%s] other:map[index:%d text:%s]]", i%2, result.Prediction, i%2, result.Prediction)
+		if got := fmt.Sprint(result.Details); got != want {
+			t.Errorf("details of result %d = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestAttachDetailsOutsideRun(t *testing.T) {
+	metric := echoPointwise("echo")
+	if _, err := metric.Compute(context.Background(), []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNestedDetails(t *testing.T) {
+	echo := echoPointwise("echo")
+	converted := echo.ToPairwise(DifferenceScore)
+	composite := NewCompositePairwiseMetric("quality", "", MinCombiner,
+		WeightedPairwiseMetric{Metric: converted, Weight: 1},
+		WeightedPairwiseMetric{Metric: constantPairwise("quiet", 1), Weight: 1},
+	)
+
+	evaluation := NewPairwiseEvaluation("nested", "", []PairwiseMetric{composite})
+	results, err := evaluation.Run(context.Background(), []Instance{{Reference: "r", Prediction: "p"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Converted metrics nest their details under reference and prediction, composites under their children
+	want := "map[quality:map[echo:map[prediction:map[index:0 text:p] reference:map[index:0 text:r]]]]"
+	if got := fmt.Sprint(results[0].Details); got != want {
+		t.Errorf("details = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,215 @@
+// Package evaltest runs evaluations in Go tests: it asserts thresholds on their summaries with failure
+// messages listing the worst offending instances, and snapshots metric scores in golden files.
+//
+//	func TestSummarizer(t *testing.T) {
+//		results := evaltest.RunPairwise(t, evaluation, instances)
+//		evaltest.AssertPairwise(t, evaluation, results, "word_overlap.mean >= 0.6", "length_ratio.p95 <= 1.5")
+//		evaltest.GoldenPairwise(t, "testdata/summarizer.golden.json", results, 1e-9)
+//	}
+//
+// Golden files are rewritten instead of compared when the tests run with -evaltest.update
+// or with the EVALTEST_UPDATE environment variable set to a true value.
+package evaltest
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// WorstInstances is the number of offending instances listed for each violated assertion
+var WorstInstances = 5
+
+// maxMismatches is the number of golden file mismatches listed before the rest are counted
+const maxMismatches = 20
+
+var update = flag.Bool("evaltest.update", false, "rewrite evaltest golden files instead of comparing against them")
+
+// RunPairwise runs a pairwise evaluation, failing the test immediately when the run fails
+func RunPairwise(tb testing.TB, evaluation *eval.PairwiseEvaluation, instances []eval.Instance) []eval.PairwiseResult {
+	tb.Helper()
+	results, err := evaluation.Run(context.Background(), instances)
+	if err != nil {
+		tb.Fatalf("evaluation %s failed: %v", evaluation.Name, err)
+	}
+	return results
+}
+
+// RunPointwise runs a pointwise evaluation, failing the test immediately when the run fails
+func RunPointwise(tb testing.TB, evaluation *eval.PointwiseEvaluation, instances []eval.PointwiseInstance) []eval.PointwiseResult {
+	tb.Helper()
+	results, err := evaluation.RunInstances(context.Background(), instances)
+	if err != nil {
+		tb.Fatalf("evaluation %s failed: %v", evaluation.Name, err)
+	}
+	return results
+}
+
+// AssertPairwise checks assertions, written as accepted by eval.ParseAssertion, against the summary of pairwise results.
+// Every violated assertion is reported along with the worst offending instances. Relative assertions fail for lack of a baseline.
+func AssertPairwise(tb testing.TB, evaluation *eval.PairwiseEvaluation, results []eval.PairwiseResult, assertions ...string) eval.Verdict {
+	tb.Helper()
+	return assertSummary(tb, evaluation.Summarize(results), pairwiseInstances(results), assertions)
+}
+
+// AssertPointwise checks assertions, written as accepted by eval.ParseAssertion, against the summary of pointwise results.
+// Every violated assertion is reported along with the worst offending instances. Relative assertions fail for lack of a baseline.
+func AssertPointwise(tb testing.TB, evaluation *eval.PointwiseEvaluation, results []eval.PointwiseResult, assertions ...string) eval.Verdict {
+	tb.Helper()
+	return assertSummary(tb, evaluation.Summarize(results), pointwiseInstances(results), assertions)
+}
+
+// instance is the part of a result shown in failure messages
+type instance struct {
+	label      string
+	prediction string
+	scores     map[string]float64
+	errors     eval.MetricErrors
+}
+
+func pairwiseInstances(results []eval.PairwiseResult) []instance {
+	instances := make([]instance, len(results))
+	for i, result := range results {
+		instances[i] = instance{label(result.Instance.ID, i), result.Instance.Prediction, result.MetricResults, result.Errors}
+	}
+	return instances
+}
+
+func pointwiseInstances(results []eval.PointwiseResult) []instance {
+	instances := make([]instance, len(results))
+	for i, result := range results {
+		instances[i] = instance{label(result.Instance.ID, i), result.Prediction, result.MetricResults, result.Errors}
+	}
+	return instances
+}
+
+// label names an instance by its ID, or by its 1-based position when it has none
+func label(id string, index int) string {
+	if id != "" {
+		return id
+	}
+	return "#" + strconv.Itoa(index+1)
+}
+
+func assertSummary(tb testing.TB, summary eval.Summary, instances []instance, texts []string) eval.Verdict {
+	tb.Helper()
+
+	assertions := make([]eval.Assertion, len(texts))
+	for i, text := range texts {
+		assertion, err := eval.ParseAssertion(text)
+		if err != nil {
+			tb.Fatalf("evaltest: %v", err)
+		}
+		assertions[i] = assertion
+	}
+
+	verdict := eval.CheckAssertions(summary, nil, assertions)
+	for _, failure := range verdict.Failures() {
+		var b strings.Builder
+		b.WriteString(failure.String())
+		writeWorstInstances(&b, failure.Assertion.Resolve(summary), instances)
+		tb.Error(b.String())
+	}
+	return verdict
+}
+
+// writeWorstInstances lists the instances pulling the statistic of a violated assertion the wrong way:
+// the failing ones for error statistics, the lowest scores for lower bounds and the highest for upper bounds
+func writeWorstInstances(b *strings.Builder, assertion eval.Assertion, instances []instance) {
+	if WorstInstances <= 0 {
+		return
+	}
+
+	if strings.HasPrefix(assertion.Statistic, "error_") {
+		var failing []instance
+		for _, inst := range instances {
+			if _, failed := inst.errors[assertion.Metric]; failed {
+				failing = append(failing, inst)
+			}
+		}
+		if len(failing) == 0 {
+			return
+		}
+		fmt.Fprintf(b, "\n  failing instances of %s (%d):", assertion.Metric, len(failing))
+		for _, inst := range failing[:min(len(failing), WorstInstances)] {
+			fmt.Fprintf(b, "\n    %s: %v", inst.label, inst.errors[assertion.Metric])
+		}
+		return
+	}
+
+	var scored []instance
+	for _, inst := range instances {
+		if score, ok := inst.scores[assertion.Metric]; ok && !math.IsNaN(score) {
+			scored = append(scored, inst)
+		}
+	}
+	if len(scored) == 0 {
+		return
+	}
+
+	highest := assertion.Operator == eval.AtMost || assertion.Operator == eval.LessThan
+	sort.SliceStable(scored, func(i, j int) bool {
+		if highest {
+			return scored[i].scores[assertion.Metric] > scored[j].scores[assertion.Metric]
+		}
+		return scored[i].scores[assertion.Metric] < scored[j].scores[assertion.Metric]
+	})
+
+	order := "lowest"
+	if highest {
+		order = "highest"
+	}
+	fmt.Fprintf(b, "\n  %s scores of %s:", order, assertion.Metric)
+	for _, inst := range scored[:min(len(scored), WorstInstances)] {
+		fmt.Fprintf(b, "\n    %s: %s  %s", inst.label, strconv.FormatFloat(inst.scores[assertion.Metric], 'g', 6, 64), excerpt(inst.prediction))
+	}
+}
+
+// excerpt quotes the start of a prediction on a single line
+func excerpt(text string) string {
+	const maxRunes = 60
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) > maxRunes {
+		return strconv.Quote(string(runes[:maxRunes]) + "…")
+	}
+	return strconv.Quote(string(runes))
+}
+
+// Near reports whether two scores differ by at most tolerance. NaN is near NaN only and
+// infinities are near infinities of the same sign only.
+func Near(got, want, tolerance float64) bool {
+	switch {
+	case math.IsNaN(got) || math.IsNaN(want):
+		return math.IsNaN(got) && math.IsNaN(want)
+	case math.IsInf(got, 0) || math.IsInf(want, 0):
+		return got == want
+	}
+	return math.Abs(got-want) <= tolerance
+}
+
+// AssertNear reports a test error when a score is not within tolerance of the expected one
+func AssertNear(tb testing.TB, name string, got, want, tolerance float64) bool {
+	tb.Helper()
+	if !Near(got, want, tolerance) {
+		tb.Errorf("%s = %g, want %g (tolerance %g)", name, got, want, tolerance)
+		return false
+	}
+	return true
+}
+
+// updating reports whether golden files are rewritten instead of compared
+func updating() bool {
+	if *update {
+		return true
+	}
+	value, err := strconv.ParseBool(os.Getenv("EVALTEST_UPDATE"))
+	return err == nil && value
+}
@@ -0,0 +1,189 @@
+package evaltest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// recorder is a testing.TB recording the errors and fatal failure it is given
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Error(args ...any) {
+	r.errors = append(r.errors, fmt.Sprint(args...))
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.fatal = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// run calls f in a goroutine of its own, so that Fatalf only stops f
+func (r *recorder) run(f func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	<-done
+}
+
+// lengthEvaluation scores predictions with their length in words, failing on empty predictions
+func lengthEvaluation() *eval.PointwiseEvaluation {
+	length := eval.NewPointwiseMetric("length", "", func(ctx context.Context, predictions []string) ([]float64, error) {
+		scores := make([]float64, len(predictions))
+		failed := eval.InstanceErrors{}
+		for i, prediction := range predictions {
+			if prediction == "" {
+				failed[i] = errors.New("empty prediction")
+			}
+			scores[i] = float64(len(strings.Fields(prediction)))
+		}
+		if len(failed) > 0 {
+			return scores, failed
+		}
+		return scores, nil
+	})
+	evaluation := eval.NewPointwiseEvaluation("lengths", "", []eval.PointwiseMetric{length})
+	evaluation.PartialFailures = true
+	return evaluation
+}
+
+var lengthInstances = []eval.PointwiseInstance{
+	{ID: "short", Prediction: "one"},
+	{ID: "long", Prediction: "one two three four"},
+	{ID: "medium", Prediction: "one two"},
+	{Prediction: ""},
+}
+
+func TestAssertPointwise(t *testing.T) {
+	evaluation := lengthEvaluation()
+	results := RunPointwise(t, evaluation, lengthInstances)
+
+	r := &recorder{TB: t}
+	verdict := AssertPointwise(r, evaluation, results, "length.mean >= 2", "length.max <= 3", "length.error_count <= 0", "length.min >= 1")
+	if verdict.Passed || len(verdict.Failures()) != 2 {
+		t.Fatalf("verdict = %+v, want the max and error assertions to fail", verdict)
+	}
+	if len(r.errors) != 2 {
+		t.Fatalf("reported %d errors, want 2: %q", len(r.errors), r.errors)
+	}
+
+	// Upper bounds list the highest scores first, with the prediction
+	if !strings.Contains(r.errors[0], "FAIL length.max <= 3: got 4") ||
+		!strings.Contains(r.errors[0], "highest scores of length:\n    long: 4  \"one two three four\"\n    medium: 2") {
+		t.Errorf("unexpected failure message:\n%s", r.errors[0])
+	}
+	// Error statistics list the failing instances, labelled by position when they have no ID
+	if !strings.Contains(r.errors[1], "failing instances of length (1):\n    #4: ") {
+		t.Errorf("unexpected failure message:\n%s", r.errors[1])
+	}
+}
+
+func TestAssertWorstInstances(t *testing.T) {
+	evaluation := lengthEvaluation()
+	results := RunPointwise(t, evaluation, lengthInstances[:3])
+
+	defer func(n int) { WorstInstances = n }(WorstInstances)
+	WorstInstances = 1
+
+	r := &recorder{TB: t}
+	AssertPointwise(r, evaluation, results, "length.median >= 3")
+	if len(r.errors) != 1 || !strings.HasSuffix(r.errors[0], "lowest scores of length:\n    short: 1  \"one\"") {
+		t.Errorf("unexpected failure messages: %q", r.errors)
+	}
+}
+
+func TestAssertInvalidAssertion(t *testing.T) {
+	evaluation := lengthEvaluation()
+	results := RunPointwise(t, evaluation, lengthInstances)
+
+	r := &recorder{TB: t}
+	r.run(func() { AssertPointwise(r, evaluation, results, "length.mean") })
+	if !strings.Contains(r.fatal, "missing operator") {
+		t.Errorf("fatal failure = %q, want a parse error", r.fatal)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	evaluation := lengthEvaluation()
+	results := RunPointwise(t, evaluation, lengthInstances[:3])
+	results[0].MetricResults["ratio"] = math.NaN()
+	results[1].MetricResults["ratio"] = math.Inf(1)
+	path := filepath.Join(t.TempDir(), "testdata", "lengths.golden.json")
+
+	r := &recorder{TB: t}
+	r.run(func() { GoldenPointwise(r, path, results, 1e-9) })
+	if !strings.Contains(r.fatal, "does not exist") {
+		t.Errorf("fatal failure = %q, want a missing golden file", r.fatal)
+	}
+
+	t.Setenv("EVALTEST_UPDATE", "true")
+	GoldenPointwise(t, path, results, 1e-9)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("EVALTEST_UPDATE", "")
+	GoldenPointwise(t, path, results, 1e-9)
+
+	results[2].MetricResults["length"] += 1e-12
+	GoldenPointwise(t, path, results, 1e-9)
+
+	results[2].MetricResults["length"] = 5
+	delete(results[0].MetricResults, "ratio")
+	results[1].MetricResults["new"] = 1
+	r = &recorder{TB: t}
+	GoldenPointwise(r, path, results, 1e-9)
+	if len(r.errors) != 1 {
+		t.Fatalf("reported %d errors, want 1: %q", len(r.errors), r.errors)
+	}
+	for _, mismatch := range []string{
+		"(3 mismatches)",
+		"long: new = 1, not in golden file",
+		"medium: length = 5, want 2",
+		"short: ratio: missing score, want NaN",
+	} {
+		if !strings.Contains(r.errors[0], mismatch) {
+			t.Errorf("golden failure does not mention %q:\n%s", mismatch, r.errors[0])
+		}
+	}
+}
+
+func TestNear(t *testing.T) {
+	tests := []struct {
+		got, want float64
+		near      bool
+	}{
+		{1, 1.05, true},
+		{1, 1.2, false},
+		{math.NaN(), math.NaN(), true},
+		{math.NaN(), 1, false},
+		{math.Inf(1), math.Inf(1), true},
+		{math.Inf(1), math.Inf(-1), false},
+		{math.Inf(1), 1e308, false},
+	}
+	for _, tt := range tests {
+		if near := Near(tt.got, tt.want, 0.1); near != tt.near {
+			t.Errorf("Near(%g, %g, 0.1) = %v, want %v", tt.got, tt.want, near, tt.near)
+		}
+	}
+}
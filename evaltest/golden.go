@@ -0,0 +1,152 @@
+package evaltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// goldenScores maps instance labels to their metric scores, NaN and infinite scores are stored as strings
+type goldenScores map[string]map[string]any
+
+// GoldenPairwise compares the scores of pairwise results with those of a golden file, within tolerance.
+// Instances are identified by their ID, or by their position when they have none.
+func GoldenPairwise(tb testing.TB, path string, results []eval.PairwiseResult, tolerance float64) {
+	tb.Helper()
+	golden(tb, path, pairwiseInstances(results), tolerance)
+}
+
+// GoldenPointwise compares the scores of pointwise results with those of a golden file, within tolerance.
+// Instances are identified by their ID, or by their position when they have none.
+func GoldenPointwise(tb testing.TB, path string, results []eval.PointwiseResult, tolerance float64) {
+	tb.Helper()
+	golden(tb, path, pointwiseInstances(results), tolerance)
+}
+
+func golden(tb testing.TB, path string, instances []instance, tolerance float64) {
+	tb.Helper()
+
+	got := make(goldenScores, len(instances))
+	for _, inst := range instances {
+		scores := make(map[string]any, len(inst.scores))
+		for name, score := range inst.scores {
+			scores[name] = encodeScore(score)
+		}
+		got[inst.label] = scores
+	}
+
+	if updating() {
+		data, err := json.MarshalIndent(got, "", "  ")
+		if err != nil {
+			tb.Fatalf("evaltest: encoding golden scores: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatalf("evaltest: %v", err)
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			tb.Fatalf("evaltest: %v", err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		tb.Fatalf("evaltest: golden file %s does not exist, run the tests with -evaltest.update to create it", path)
+	}
+	if err != nil {
+		tb.Fatalf("evaltest: %v", err)
+	}
+	var want goldenScores
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&want); err != nil {
+		tb.Fatalf("evaltest: %s: %v", path, err)
+	}
+
+	mismatches := compareGolden(got, want, tolerance)
+	if len(mismatches) == 0 {
+		return
+	}
+	shown := mismatches
+	if len(shown) > maxMismatches {
+		shown = shown[:maxMismatches]
+	}
+	message := fmt.Sprintf("scores differ from golden file %s (%d mismatches):\n  %s", path, len(mismatches), strings.Join(shown, "\n  "))
+	if len(mismatches) > len(shown) {
+		message += fmt.Sprintf("\n  ... and %d more", len(mismatches)-len(shown))
+	}
+	tb.Error(message + "\nrun the tests with -evaltest.update to accept the new scores")
+}
+
+// compareGolden describes every difference between the scores and the golden scores, sorted
+func compareGolden(got, want goldenScores, tolerance float64) []string {
+	var mismatches []string
+	for label, wantScores := range want {
+		gotScores, ok := got[label]
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s: missing instance", label))
+			continue
+		}
+		for name, wantValue := range wantScores {
+			gotValue, ok := gotScores[name]
+			if !ok {
+				mismatches = append(mismatches, fmt.Sprintf("%s: %s: missing score, want %v", label, name, wantValue))
+				continue
+			}
+			gotScore, wantScore := decodeScore(gotValue), decodeScore(wantValue)
+			if !Near(gotScore, wantScore, tolerance) {
+				mismatches = append(mismatches, fmt.Sprintf("%s: %s = %g, want %g", label, name, gotScore, wantScore))
+			}
+		}
+		for name, gotValue := range gotScores {
+			if _, ok := wantScores[name]; !ok {
+				mismatches = append(mismatches, fmt.Sprintf("%s: %s = %v, not in golden file", label, name, gotValue))
+			}
+		}
+	}
+	for label := range got {
+		if _, ok := want[label]; !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s: instance not in golden file", label))
+		}
+	}
+	sort.Strings(mismatches)
+	return mismatches
+}
+
+// encodeScore returns a score as a JSON encodable value, NaN and infinities become strings
+func encodeScore(score float64) any {
+	if math.IsNaN(score) || math.IsInf(score, 0) {
+		return fmt.Sprint(score)
+	}
+	return score
+}
+
+// decodeScore reads a score encoded by encodeScore, unreadable values are NaN
+func decodeScore(value any) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case json.Number:
+		score, err := v.Float64()
+		if err == nil {
+			return score
+		}
+	case string:
+		switch v {
+		case "+Inf":
+			return math.Inf(1)
+		case "-Inf":
+			return math.Inf(-1)
+		}
+	}
+	return math.NaN()
+}
@@ -7,37 +7,51 @@ import (
 
 // PairwiseEvaluation represents a set of metrics that compare references and predictions
 type PairwiseEvaluation struct {
-	Name    string
+	Name        string
 	Description string
-	metrics []PairwiseMetric
+	// Concurrency is the maximum number of metric computations run at once, values below 2 run them sequentially
+	Concurrency int
+	// ShardSize splits the instances into batches of at most this many instances per metric computation, 0 disables sharding
+	ShardSize int
+	// PartialFailures records failing metrics in each result's Errors instead of aborting the run
+	PartialFailures bool
+	metrics         []PairwiseMetric
 }
 
 // PointwiseEvaluation represents a set of metrics that evaluate predictions
 type PointwiseEvaluation struct {
-	Name    string
+	Name        string
 	Description string
-	metrics []PointwiseMetric
+	// Concurrency is the maximum number of metric computations run at once, values below 2 run them sequentially
+	Concurrency int
+	// ShardSize splits the predictions into batches of at most this many predictions per metric computation, 0 disables sharding
+	ShardSize int
+	// PartialFailures records failing metrics in each result's Errors instead of aborting the run
+	PartialFailures bool
+	metrics         []PointwiseMetric
 }
 
 // NewPairwiseEvaluation creates a new pairwise evaluation
 func NewPairwiseEvaluation(name, description string, metrics []PairwiseMetric) *PairwiseEvaluation {
 	return &PairwiseEvaluation{
-		Name:    name,
+		Name:        name,
 		Description: description,
-		metrics: metrics,
+		metrics:     metrics,
 	}
 }
 
 // NewPointwiseEvaluation creates a new pointwise evaluation
 func NewPointwiseEvaluation(name, description string, metrics []PointwiseMetric) *PointwiseEvaluation {
 	return &PointwiseEvaluation{
-		Name:    name,
+		Name:        name,
 		Description: description,
-		metrics: metrics,
+		metrics:     metrics,
 	}
 }
 
-// Run executes the pairwise evaluation on the given instances
+// Run executes the pairwise evaluation on the given instances.
+// Every metric is computed over every shard of instances, fanned out over at most
+// Concurrency goroutines; results keep the order of the instances.
 func (e *PairwiseEvaluation) Run(ctx context.Context, instances []Instance) ([]PairwiseResult, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
@@ -47,33 +61,92 @@ func (e *PairwiseEvaluation) Run(ctx context.Context, instances []Instance) ([]P
 		return nil, fmt.Errorf("no instances provided")
 	}
 
-	// Extract references and predictions from instances
+	// Extract references and predictions from instances, switching every metric to
+	// multi-reference mode as soon as one instance has further references
 	references := make([]string, len(instances))
 	predictions := make([]string, len(instances))
+	ids := make([]string, len(instances))
+	multiReference := false
 	for i, instance := range instances {
+		ids[i] = instance.ID
 		references[i] = instance.Reference
 		predictions[i] = instance.Prediction
+		multiReference = multiReference || len(instance.References) > 0
+	}
+	if err := checkUniqueIDs(ids); err != nil {
+		return nil, err
+	}
+	var referenceSets [][]string
+	if multiReference {
+		referenceSets = make([][]string, len(instances))
+		for i, instance := range instances {
+			referenceSets[i] = instance.AllReferences()
+		}
 	}
 
-	// Initialize results
-	results := make([]PairwiseResult, len(instances))
-	for i := range instances {
-		results[i] = PairwiseResult{
-			Instance:      instances[i],
-			MetricResults: make(map[string]float64),
+	// Run metrics, each job writes to its own metric and shard so no locking is needed
+	shards := splitShards(len(instances), e.ShardSize)
+	names := make([]string, len(e.metrics))
+	keys := make([][]string, len(e.metrics))
+	scores := make([][][]float64, len(e.metrics))
+	details := make([][]Details, len(e.metrics))
+	for m := range scores {
+		names[m] = e.metrics[m].Name
+		keys[m] = e.metrics[m].ResultKeys()
+		scores[m] = newColumns(len(keys[m]), len(instances))
+		details[m] = make([]Details, len(instances))
+	}
+	if err := checkResultKeys(names, keys); err != nil {
+		return nil, err
+	}
+	var errs [][]error
+	if e.PartialFailures {
+		errs = make([][]error, len(e.metrics))
+		for m := range errs {
+			errs[m] = make([]error, len(instances))
 		}
 	}
 
-	// Run metrics
-	for _, metric := range e.metrics {
-		scores, err := metric.Compute(ctx, references, predictions)
+	err := runJobs(ctx, e.Concurrency, len(e.metrics)*len(shards), func(ctx context.Context, job int) error {
+		m, s := job/len(shards), shards[job%len(shards)]
+		metric := &e.metrics[m]
+
+		ctx, recorder := recordDetails(withInstances(ctx, instances[s.start:s.end:s.end]))
+		var shardScores [][]float64
+		var err error
+		if multiReference {
+			shardScores, err = metric.computeColumns(ctx, nil, referenceSets[s.start:s.end:s.end], predictions[s.start:s.end:s.end])
+		} else {
+			shardScores, err = metric.computeColumns(ctx, references[s.start:s.end:s.end], nil, predictions[s.start:s.end:s.end])
+		}
+		copyDetails(details[m], recorder, s)
+		if err == nil {
+			err = checkColumns(shardScores, len(keys[m]), s.end-s.start)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("metric %s failed: %w", metric.Name, err)
+			if !e.PartialFailures || ctx.Err() != nil {
+				return fmt.Errorf("metric %s failed: %w", metric.Name, err)
+			}
+			recordFailures(errs[m], scores[m], shardScores, s, err)
+			return nil
 		}
 
-		for i, score := range scores {
-			results[i].MetricResults[metric.Name] = score
+		copyColumns(scores[m], shardScores, s)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge scores into results
+	results := make([]PairwiseResult, len(instances))
+	for i := range instances {
+		results[i] = PairwiseResult{
+			Instance:      instances[i],
+			MetricResults: make(map[string]float64, len(e.metrics)),
 		}
+		results[i].Errors = mergeScores(results[i].MetricResults, keys, scores, errs, i)
+		results[i].Details = mergeDetails(names, details, i)
 	}
 
 	return results, nil
@@ -81,34 +154,128 @@ func (e *PairwiseEvaluation) Run(ctx context.Context, instances []Instance) ([]P
 
 // Run executes the pointwise evaluation on the given predictions
 func (e *PointwiseEvaluation) Run(ctx context.Context, predictions []string) ([]PointwiseResult, error) {
+	instances := make([]PointwiseInstance, len(predictions))
+	for i, prediction := range predictions {
+		instances[i] = PointwiseInstance{Prediction: prediction}
+	}
+	return e.RunInstances(ctx, instances)
+}
+
+// RunInstances executes the pointwise evaluation on the given instances.
+// Every metric is computed over every shard of instances, fanned out over at most
+// Concurrency goroutines; results keep the order of the instances.
+func (e *PointwiseEvaluation) RunInstances(ctx context.Context, instances []PointwiseInstance) ([]PointwiseResult, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	if len(predictions) == 0 {
+	if len(instances) == 0 {
 		return nil, fmt.Errorf("no predictions provided")
 	}
 
-	// Initialize results
-	results := make([]PointwiseResult, len(predictions))
-	for i, prediction := range predictions {
-		results[i] = PointwiseResult{
-			Prediction:    prediction,
-			MetricResults: make(map[string]float64),
+	// Extract predictions from instances
+	predictions := make([]string, len(instances))
+	ids := make([]string, len(instances))
+	for i, instance := range instances {
+		predictions[i] = instance.Prediction
+		ids[i] = instance.ID
+	}
+	if err := checkUniqueIDs(ids); err != nil {
+		return nil, err
+	}
+
+	// Run metrics, each job writes to its own metric and shard so no locking is needed
+	shards := splitShards(len(predictions), e.ShardSize)
+	names := make([]string, len(e.metrics))
+	keys := make([][]string, len(e.metrics))
+	scores := make([][][]float64, len(e.metrics))
+	details := make([][]Details, len(e.metrics))
+	for m := range scores {
+		names[m] = e.metrics[m].Name
+		keys[m] = e.metrics[m].ResultKeys()
+		scores[m] = newColumns(len(keys[m]), len(predictions))
+		details[m] = make([]Details, len(predictions))
+	}
+	if err := checkResultKeys(names, keys); err != nil {
+		return nil, err
+	}
+	var errs [][]error
+	if e.PartialFailures {
+		errs = make([][]error, len(e.metrics))
+		for m := range errs {
+			errs[m] = make([]error, len(predictions))
 		}
 	}
 
-	// Run metrics
-	for _, metric := range e.metrics {
-		scores, err := metric.Compute(ctx, predictions)
+	err := runJobs(ctx, e.Concurrency, len(e.metrics)*len(shards), func(ctx context.Context, job int) error {
+		m, s := job/len(shards), shards[job%len(shards)]
+		metric := &e.metrics[m]
+
+		ctx, recorder := recordDetails(withPointwiseInstances(ctx, instances[s.start:s.end:s.end]))
+		shardScores, err := metric.computeColumns(ctx, predictions[s.start:s.end:s.end])
+		copyDetails(details[m], recorder, s)
+		if err == nil {
+			err = checkColumns(shardScores, len(keys[m]), s.end-s.start)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("metric %s failed: %w", metric.Name, err)
+			if !e.PartialFailures || ctx.Err() != nil {
+				return fmt.Errorf("metric %s failed: %w", metric.Name, err)
+			}
+			recordFailures(errs[m], scores[m], shardScores, s, err)
+			return nil
 		}
 
-		for i, score := range scores {
-			results[i].MetricResults[metric.Name] = score
+		copyColumns(scores[m], shardScores, s)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge scores into results
+	results := make([]PointwiseResult, len(predictions))
+	for i, instance := range instances {
+		results[i] = PointwiseResult{
+			Prediction:    instance.Prediction,
+			Instance:      instance,
+			MetricResults: make(map[string]float64, len(e.metrics)),
 		}
+		results[i].Errors = mergeScores(results[i].MetricResults, keys, scores, errs, i)
+		results[i].Details = mergeDetails(names, details, i)
 	}
 
 	return results, nil
-} 
\ No newline at end of file
+}
+
+// checkUniqueIDs returns an error naming the first ID set on more than one instance, instances without an ID are not checked
+func checkUniqueIDs(ids []string) error {
+	seen := make(map[string]int, len(ids))
+	for i, id := range ids {
+		if id == "" {
+			continue
+		}
+		if first, ok := seen[id]; ok {
+			return fmt.Errorf("instances %d and %d share the ID %q", first, i, id)
+		}
+		seen[id] = i
+	}
+	return nil
+}
+
+// checkResultKeys returns an error for a result key recorded by more than one metric or output of a metric,
+// whose scores would overwrite each other
+func checkResultKeys(names []string, keys [][]string) error {
+	seen := make(map[string]int)
+	for m, metricKeys := range keys {
+		for _, key := range metricKeys {
+			if other, ok := seen[key]; ok {
+				if other == m {
+					return fmt.Errorf("metric %s records two scores under %q", names[m], key)
+				}
+				return fmt.Errorf("metric %s records scores under %q like the earlier metric %s", names[m], key, names[other])
+			}
+			seen[key] = m
+		}
+	}
+	return nil
+}
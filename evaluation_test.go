@@ -0,0 +1,117 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// failingOn returns a pairwise metric scoring 1 except on predictions equal to fail, reported as InstanceErrors
+func failingOn(name, fail string) PairwiseMetric {
+	return NewPairwiseMetric(name, "", func(ctx context.Context, references, predictions []string) ([]float64, error) {
+		scores := make([]float64, len(predictions))
+		failed := InstanceErrors{}
+		for i, prediction := range predictions {
+			if prediction == fail {
+				failed[i] = errors.New("cannot score " + prediction)
+				continue
+			}
+			scores[i] = 1
+		}
+		if len(failed) > 0 {
+			return scores, failed
+		}
+		return scores, nil
+	})
+}
+
+func TestRunPartialFailures(t *testing.T) {
+	broken := NewPairwiseMetric("broken", "", func(ctx context.Context, references, predictions []string) ([]float64, error) {
+		for _, prediction := range predictions {
+			if prediction == "d" {
+				return nil, errors.New("batch failed")
+			}
+		}
+		return make([]float64, len(predictions)), nil
+	})
+	instances := []Instance{{Prediction: "a"}, {Prediction: "b"}, {Prediction: "c"}, {Prediction: "d"}}
+
+	for _, shardSize := range []int{0, 2} {
+		evaluation := NewPairwiseEvaluation("partial", "", []PairwiseMetric{failingOn("picky", "c"), broken, constantPairwise("healthy", 0.5)})
+		evaluation.PartialFailures = true
+		evaluation.ShardSize = shardSize
+
+		results, err := evaluation.Run(context.Background(), instances)
+		if err != nil {
+			t.Fatalf("shard size %d: %v", shardSize, err)
+		}
+		for i, result := range results {
+			if result.MetricResults["healthy"] != 0.5 {
+				t.Errorf("shard size %d: healthy score of instance %d = %g, want 0.5", shardSize, i, result.MetricResults["healthy"])
+			}
+
+			_, pickyFailed := result.Errors["picky"]
+			if pickyFailed != (i == 2) {
+				t.Errorf("shard size %d: picky failed on instance %d = %v", shardSize, i, pickyFailed)
+			}
+			if score, ok := result.MetricResults["picky"]; ok == pickyFailed || (ok && score != 1) {
+				t.Errorf("shard size %d: picky score of instance %d = %g, %v", shardSize, i, score, ok)
+			}
+
+			// The broken metric fails the whole batch holding d
+			_, brokenFailed := result.Errors["broken"]
+			if want := shardSize == 0 || i >= 2; brokenFailed != want {
+				t.Errorf("shard size %d: broken failed on instance %d = %v, want %v", shardSize, i, brokenFailed, want)
+			}
+		}
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	evaluation := NewPairwiseEvaluation("strict", "", []PairwiseMetric{failingOn("picky", "b")})
+	_, err := evaluation.Run(context.Background(), []Instance{{Prediction: "a"}, {Prediction: "b"}})
+	var instanceErrs InstanceErrors
+	if err == nil || !strings.Contains(err.Error(), "metric picky failed") || !errors.As(err, &instanceErrs) {
+		t.Errorf("Run error = %v, want the failure of picky", err)
+	}
+
+	if _, err := evaluation.Run(context.Background(), nil); err == nil {
+		t.Error("Run without instances succeeded, want an error")
+	}
+}
+
+func TestRunPointwisePartialFailures(t *testing.T) {
+	length := NewPointwiseMetric("length", "", func(ctx context.Context, predictions []string) ([]float64, error) {
+		scores := make([]float64, len(predictions))
+		failed := InstanceErrors{}
+		for i, prediction := range predictions {
+			if prediction == "" {
+				failed[i] = errors.New("empty")
+			}
+			scores[i] = float64(len(prediction))
+		}
+		if len(failed) > 0 {
+			return scores, failed
+		}
+		return scores, nil
+	})
+	evaluation := NewPointwiseEvaluation("lengths", "", []PointwiseMetric{length})
+	evaluation.PartialFailures = true
+
+	results, err := evaluation.Run(context.Background(), []string{"abc", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].MetricResults["length"] != 3 || results[0].Errors != nil {
+		t.Errorf("first result = %+v, want a length of 3", results[0])
+	}
+	if _, ok := results[1].Errors["length"]; !ok {
+		t.Errorf("second result = %+v, want a failure", results[1])
+	}
+
+	summary := evaluation.Summarize(results)
+	if metric, _ := summary.Metric("length"); metric.Count != 1 || metric.ErrorCount != 1 {
+		t.Errorf("summary = %+v, want one score and one error", metric)
+	}
+}
@@ -1,3 +1,7 @@
 module github.com/snpu/eval-go
 
 go 1.23.3
+
+require gopkg.in/yaml.v3 v3.0.1
+
+require golang.org/x/text v0.28.0
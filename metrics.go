@@ -2,6 +2,7 @@ package eval
 
 import (
 	"context"
+	"errors"
 	"fmt"
 )
 
@@ -9,14 +10,34 @@ import (
 type PairwiseMetric struct {
 	Name        string
 	Description string
-	compute     PairwiseMetricFunc
+	// Aggregator reduces the metric's scores in run summaries, the zero value means MeanAggregator
+	Aggregator Aggregator
+	// ReferenceReducer reduces the scores of a prediction against each of its references, the zero value means MaxAggregator
+	ReferenceReducer Aggregator
+	compute          PairwiseMetricFunc
+	computeMulti     MultiReferencePairwiseMetricFunc
+	// outputs names the scores of metrics reporting several per instance, "" standing for the metric's own score.
+	// They are computed together, one column per output, by computeOutputs or computeOutputsMulti.
+	outputs             []string
+	computeOutputs      func(ctx context.Context, references, predictions []string) ([][]float64, error)
+	computeOutputsMulti func(ctx context.Context, references [][]string, predictions []string) ([][]float64, error)
+	// outputAggregators summarizes the outputs set in it with an aggregator of their own instead of Aggregator
+	outputAggregators map[string]Aggregator
 }
 
 // PointwiseMetric represents a metric that evaluates a prediction
 type PointwiseMetric struct {
 	Name        string
 	Description string
-	compute     PointwiseMetricFunc
+	// Aggregator reduces the metric's scores in run summaries, the zero value means MeanAggregator
+	Aggregator Aggregator
+	compute    PointwiseMetricFunc
+	// outputs names the scores of metrics reporting several per instance, "" standing for the metric's own score.
+	// They are computed together, one column per output, by computeOutputs.
+	outputs        []string
+	computeOutputs func(ctx context.Context, predictions []string) ([][]float64, error)
+	// outputAggregators summarizes the outputs set in it with an aggregator of their own instead of Aggregator
+	outputAggregators map[string]Aggregator
 }
 
 // Compute executes the pairwise metric on the given references and predictions
@@ -34,6 +55,19 @@ func (m *PairwiseMetric) Compute(ctx context.Context, references, predictions []
 			len(references), len(predictions))
 	}
 
+	if m.outputs != nil {
+		return m.ownScores(m.computeColumns(ctx, references, nil, predictions))
+	}
+
+	// Metrics created for multiple references score each prediction against its single reference
+	if m.compute == nil {
+		referenceSets := make([][]string, len(references))
+		for i, reference := range references {
+			referenceSets[i] = []string{reference}
+		}
+		return m.computeMulti(ctx, referenceSets, predictions)
+	}
+
 	return m.compute(ctx, references, predictions)
 }
 
@@ -47,9 +81,117 @@ func (m *PointwiseMetric) Compute(ctx context.Context, predictions []string) ([]
 		return nil, fmt.Errorf("no predictions provided")
 	}
 
+	if m.outputs != nil {
+		return m.ownScores(m.computeColumns(ctx, predictions))
+	}
 	return m.compute(ctx, predictions)
 }
 
+// ResultKeys returns the keys the metric's scores are recorded under in MetricResults: the metric's
+// name for its own score and "name.output" for the further scores of metrics reporting several
+func (m *PairwiseMetric) ResultKeys() []string {
+	return resultKeys(m.Name, m.outputs)
+}
+
+// ResultKeys returns the keys the metric's scores are recorded under in MetricResults: the metric's
+// name for its own score and "name.output" for the further scores of metrics reporting several
+func (m *PointwiseMetric) ResultKeys() []string {
+	return resultKeys(m.Name, m.outputs)
+}
+
+func resultKeys(name string, outputs []string) []string {
+	if outputs == nil {
+		return []string{name}
+	}
+	keys := make([]string, len(outputs))
+	for i, output := range outputs {
+		keys[i] = name
+		if output != "" {
+			keys[i] += "." + output
+		}
+	}
+	return keys
+}
+
+// computeColumns computes every score of the metric over a batch, one column per result key.
+// Multi-reference batches are passed as referenceSets, references is ignored then.
+func (m *PairwiseMetric) computeColumns(ctx context.Context, references []string, referenceSets [][]string, predictions []string) ([][]float64, error) {
+	if m.outputs == nil {
+		var scores []float64
+		var err error
+		if referenceSets != nil {
+			scores, err = m.ComputeMultiReference(ctx, referenceSets, predictions)
+		} else {
+			scores, err = m.Compute(ctx, references, predictions)
+		}
+		if scores == nil {
+			return nil, err
+		}
+		return [][]float64{scores}, err
+	}
+
+	if referenceSets == nil && m.computeOutputs != nil {
+		return m.computeOutputs(ctx, references, predictions)
+	}
+	if referenceSets == nil {
+		referenceSets = make([][]string, len(references))
+		for i, reference := range references {
+			referenceSets[i] = []string{reference}
+		}
+	}
+	if m.computeOutputsMulti == nil {
+		return m.computeFlattened(ctx, referenceSets, predictions, len(m.outputs), m.computeOutputs)
+	}
+	return m.computeOutputsMulti(ctx, referenceSets, predictions)
+}
+
+// computeColumns computes every score of the metric over a batch, one column per result key
+func (m *PointwiseMetric) computeColumns(ctx context.Context, predictions []string) ([][]float64, error) {
+	if m.outputs == nil {
+		scores, err := m.Compute(ctx, predictions)
+		if scores == nil {
+			return nil, err
+		}
+		return [][]float64{scores}, err
+	}
+	return m.computeOutputs(ctx, predictions)
+}
+
+// ownScores picks the metric's own scores out of the columns computed by computeColumns
+func (m *PairwiseMetric) ownScores(columns [][]float64, err error) ([]float64, error) {
+	return ownScores(m.Name, m.outputs, columns, err)
+}
+
+// ownScores picks the metric's own scores out of the columns computed by computeColumns
+func (m *PointwiseMetric) ownScores(columns [][]float64, err error) ([]float64, error) {
+	return ownScores(m.Name, m.outputs, columns, err)
+}
+
+func ownScores(name string, outputs []string, columns [][]float64, err error) ([]float64, error) {
+	for i, output := range outputs {
+		if output == "" {
+			if i < len(columns) {
+				return columns[i], err
+			}
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("metric %s has no score of its own", name)
+}
+
+// checkColumns returns an error when columns does not hold one score per instance for each of want outputs
+func checkColumns(columns [][]float64, want, instances int) error {
+	if len(columns) != want {
+		return fmt.Errorf("returned %d score columns for %d outputs", len(columns), want)
+	}
+	for _, column := range columns {
+		if len(column) != instances {
+			return fmt.Errorf("returned %d scores for %d instances", len(column), instances)
+		}
+	}
+	return nil
+}
+
 // NewPairwiseMetric creates a new pairwise metric
 func NewPairwiseMetric(name, description string, compute PairwiseMetricFunc) PairwiseMetric {
 	return PairwiseMetric{
@@ -68,34 +210,133 @@ func NewPointwiseMetric(name, description string, compute PointwiseMetricFunc) P
 	}
 }
 
+// WithAggregator returns a copy of the metric that is summarized with the given aggregator
+func (m PairwiseMetric) WithAggregator(aggregator Aggregator) PairwiseMetric {
+	m.Aggregator = aggregator
+	return m
+}
+
+// WithAggregator returns a copy of the metric that is summarized with the given aggregator
+func (m PointwiseMetric) WithAggregator(aggregator Aggregator) PointwiseMetric {
+	m.Aggregator = aggregator
+	return m
+}
+
 // ToPairwise converts a pointwise metric into a pairwise one
-// by allowing custom logic to determine the score between reference and prediction
+// by allowing custom logic to determine the score between reference and prediction.
+// Metrics reporting several scores apply the score function to each of them.
+// Details attached while scoring references and predictions are nested under "reference" and "prediction".
 func (m *PointwiseMetric) ToPairwise(scoreFunc PairwiseScoreFunc) PairwiseMetric {
-	return NewPairwiseMetric(
-		m.Name,
-		m.Description,
-		func(ctx context.Context, references, predictions []string) ([]float64, error) {
-			// Get scores for references
-			referenceScores, err := m.Compute(ctx, references)
-			if err != nil {
-				return nil, err
-			}
-			
-			// Get scores for predictions
-			predictionScores, err := m.Compute(ctx, predictions)
-			if err != nil {
-				return nil, err
+	outputs := len(m.ResultKeys())
+	computeColumns := func(ctx context.Context, references, predictions []string) ([][]float64, error) {
+		// Score references and predictions as pointwise instances of their own, keeping the instance details
+		referenceCtx, predictionCtx := ctx, ctx
+		if instances, ok := InstancesFromContext(ctx); ok && len(instances) == len(references) {
+			referenceInstances := PointwiseInstances(instances)
+			for i := range referenceInstances {
+				referenceInstances[i].Prediction = references[i]
 			}
-			
-			// Apply the custom scoring function to each pair
-			scores := make([]float64, len(references))
+			referenceCtx = withPointwiseInstances(ctx, referenceInstances)
+			predictionCtx = withPointwiseInstances(ctx, PointwiseInstances(instances))
+		}
+		
+		// Get scores for references, nesting their details apart from those of the predictions
+		var referenceScores, predictionScores [][]float64
+		var err error
+		nestDetails(referenceCtx, "reference", func(ctx context.Context) {
+			referenceScores, err = m.computeColumns(ctx, references)
+		})
+		failed, err := collectInstanceErrors(nil, err, columnsLen(referenceScores, outputs), len(references))
+		if err != nil {
+			return nil, err
+		}
+		
+		// Get scores for predictions
+		nestDetails(predictionCtx, "prediction", func(ctx context.Context) {
+			predictionScores, err = m.computeColumns(ctx, predictions)
+		})
+		failed, err = collectInstanceErrors(failed, err, columnsLen(predictionScores, outputs), len(predictions))
+		if err != nil {
+			return nil, err
+		}
+		if err := checkColumns(referenceScores, outputs, len(references)); err != nil {
+			return nil, err
+		}
+		if err := checkColumns(predictionScores, outputs, len(predictions)); err != nil {
+			return nil, err
+		}
+		
+		// Apply the custom scoring function to each pair
+		columns := newColumns(outputs, len(references))
+		for c := range columns {
 			for i := range references {
-				scores[i] = scoreFunc(referenceScores[i], predictionScores[i])
+				columns[c][i] = scoreFunc(referenceScores[c][i], predictionScores[c][i])
 			}
-			
-			return scores, nil
-		},
-	)
+		}
+		
+		// Report instances where either side failed alongside the remaining scores
+		if len(failed) > 0 {
+			return columns, failed
+		}
+		return columns, nil
+	}
+
+	if m.outputs == nil {
+		return NewPairwiseMetric(
+			m.Name,
+			m.Description,
+			func(ctx context.Context, references, predictions []string) ([]float64, error) {
+				columns, err := computeColumns(ctx, references, predictions)
+				if columns == nil {
+					return nil, err
+				}
+				return columns[0], err
+			},
+		)
+	}
+	return PairwiseMetric{
+		Name:              m.Name,
+		Description:       m.Description,
+		outputs:           m.outputs,
+		computeOutputs:    computeColumns,
+		outputAggregators: m.outputAggregators,
+	}
+}
+
+// columnsLen returns the number of instances scored in every one of want columns, -1 when they are incomplete
+func columnsLen(columns [][]float64, want int) int {
+	if len(columns) != want || want == 0 {
+		return -1
+	}
+	for _, column := range columns {
+		if len(column) != len(columns[0]) {
+			return -1
+		}
+	}
+	return len(columns[0])
+}
+
+// collectInstanceErrors merges the per-instance failures reported by err into errs.
+// Any error that is not an InstanceErrors covering a full batch of want scores is returned as is.
+func collectInstanceErrors(errs InstanceErrors, err error, got, want int) (InstanceErrors, error) {
+	if err == nil {
+		return errs, nil
+	}
+
+	var instanceErrs InstanceErrors
+	if !errors.As(err, &instanceErrs) || got != want {
+		return errs, err
+	}
+
+	if errs == nil {
+		errs = make(InstanceErrors, len(instanceErrs))
+	}
+	for i, instanceErr := range instanceErrs {
+		if _, ok := errs[i]; !ok {
+			errs[i] = instanceErr
+		}
+	}
+	return errs, nil
 }
 
 // Default scoring functions for converting pointwise metrics to pairwise
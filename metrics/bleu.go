@@ -0,0 +1,358 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// BLEUSmoothing identifies how BLEU smooths n-gram precisions without any match, following SacreBLEU
+type BLEUSmoothing string
+
+const (
+	// SmoothNone leaves precisions without any match at 0
+	SmoothNone BLEUSmoothing = "none"
+	// SmoothFloor replaces the count of matches of precisions without any match by SmoothValue, 0.1 by default
+	SmoothFloor BLEUSmoothing = "floor"
+	// SmoothAddK adds SmoothValue, 1 by default, to the matches and totals of every order above 1
+	SmoothAddK BLEUSmoothing = "add-k"
+	// SmoothExp halves the precision of each further order without any match, as mteval-v13a does
+	SmoothExp BLEUSmoothing = "exp"
+)
+
+// BLEUTokenizer identifies how BLEU splits texts into tokens, following SacreBLEU
+type BLEUTokenizer string
+
+const (
+	// Tokenize13a splits punctuation and symbols like mteval-v13a, the default of SacreBLEU
+	Tokenize13a BLEUTokenizer = "13a"
+	// TokenizeIntl splits Unicode punctuation and symbols like mteval-v14 international
+	TokenizeIntl BLEUTokenizer = "intl"
+	// TokenizeNone splits texts on whitespace only, for texts that are already tokenized
+	TokenizeNone BLEUTokenizer = "none"
+)
+
+// BLEUOptions configures BLEU, the zero value matches the defaults of SacreBLEU
+type BLEUOptions struct {
+	// MaxOrder is the largest n-gram order, 0 means 4
+	MaxOrder int
+	// Smoothing is the smoothing method, the empty string means SmoothExp
+	Smoothing BLEUSmoothing
+	// SmoothValue is the value used by SmoothFloor and SmoothAddK, 0 means their default
+	SmoothValue float64
+	// Tokenizer splits texts into tokens, the empty string means Tokenize13a
+	Tokenizer BLEUTokenizer
+	// Lowercase lowercases texts before tokenizing them
+	Lowercase bool
+}
+
+// BLEUScore holds a BLEU score and the statistics it was computed from
+type BLEUScore struct {
+	// Score is the BLEU score between 0 and 100, as reported by SacreBLEU
+	Score float64
+	// Precisions holds the n-gram precisions between 0 and 100, from unigrams up to MaxOrder
+	Precisions     []float64
+	BrevityPenalty float64
+	// HypothesisLength and ReferenceLength count the tokens of the predictions and of their closest references
+	HypothesisLength int
+	ReferenceLength  int
+}
+
+// withDefaults returns the options with their zero values replaced by defaults, or an error for invalid options
+func (o BLEUOptions) withDefaults() (BLEUOptions, error) {
+	if o.MaxOrder == 0 {
+		o.MaxOrder = 4
+	}
+	if o.MaxOrder < 0 {
+		return o, fmt.Errorf("invalid BLEU max order %d", o.MaxOrder)
+	}
+	if o.Smoothing == "" {
+		o.Smoothing = SmoothExp
+	}
+	if o.SmoothValue == 0 {
+		switch o.Smoothing {
+		case SmoothFloor:
+			o.SmoothValue = 0.1
+		case SmoothAddK:
+			o.SmoothValue = 1
+		}
+	}
+	switch o.Smoothing {
+	case SmoothNone, SmoothFloor, SmoothAddK, SmoothExp:
+	default:
+		return o, fmt.Errorf("unknown BLEU smoothing %q, expected none, floor, add-k or exp", o.Smoothing)
+	}
+	if o.Tokenizer == "" {
+		o.Tokenizer = Tokenize13a
+	}
+	if _, ok := bleuTokenizers[o.Tokenizer]; !ok {
+		return o, fmt.Errorf("unknown BLEU tokenizer %q, expected 13a, intl or none", o.Tokenizer)
+	}
+	return o, nil
+}
+
+// BLEU returns a pairwise metric that computes the sentence-level BLEU score of each prediction, between 0 and 100,
+// like SacreBLEU's sentence_bleu. Predictions with several references are scored against all of them at once.
+// Its details hold the n-gram precisions, the brevity penalty and the token counts.
+// Corpus-level BLEU is not the mean of sentence-level scores, compute it over a whole run with CorpusBLEU.
+func BLEU(opts BLEUOptions) eval.PairwiseMetric {
+	return eval.NewMultiReferencePairwiseMetric(
+		"bleu",
+		"Computes the sentence-level BLEU score between 0 and 100",
+		func(ctx context.Context, references [][]string, predictions []string) ([]float64, error) {
+			opts, err := opts.withDefaults()
+			if err != nil {
+				return nil, err
+			}
+
+			scores := make([]float64, len(predictions))
+			for i, prediction := range predictions {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				stats := newBLEUStats(opts.MaxOrder)
+				stats.add(prediction, references[i], opts)
+				score := stats.score(opts, true)
+				scores[i] = score.Score
+				eval.AttachDetails(ctx, i, eval.Details{
+					"precisions":        score.Precisions,
+					"brevity_penalty":   score.BrevityPenalty,
+					"hypothesis_length": score.HypothesisLength,
+					"reference_length":  score.ReferenceLength,
+				})
+			}
+			return scores, nil
+		},
+	)
+}
+
+// SentenceBLEU computes the BLEU score of a prediction against its references like SacreBLEU's sentence_bleu,
+// which only averages the n-gram orders the prediction is long enough for
+func SentenceBLEU(references []string, prediction string, opts BLEUOptions) (BLEUScore, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return BLEUScore{}, err
+	}
+	stats := newBLEUStats(opts.MaxOrder)
+	stats.add(prediction, references, opts)
+	return stats.score(opts, true), nil
+}
+
+// CorpusBLEU computes the BLEU score of predictions against their references like SacreBLEU's corpus_bleu,
+// from the n-gram statistics summed over every prediction. references[i] holds the references of predictions[i].
+func CorpusBLEU(references [][]string, predictions []string, opts BLEUOptions) (BLEUScore, error) {
+	if len(references) != len(predictions) {
+		return BLEUScore{}, fmt.Errorf("number of references (%d) does not match number of predictions (%d)",
+			len(references), len(predictions))
+	}
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return BLEUScore{}, err
+	}
+
+	stats := newBLEUStats(opts.MaxOrder)
+	for i, prediction := range predictions {
+		stats.add(prediction, references[i], opts)
+	}
+	return stats.score(opts, false), nil
+}
+
+// CorpusBLEUResults computes the corpus-level BLEU score of the results of a pairwise evaluation,
+// scoring each prediction against every reference of its instance
+func CorpusBLEUResults(results []eval.PairwiseResult, opts BLEUOptions) (BLEUScore, error) {
+	references := make([][]string, len(results))
+	predictions := make([]string, len(results))
+	for i, result := range results {
+		references[i] = result.Instance.AllReferences()
+		predictions[i] = result.Instance.Prediction
+	}
+	return CorpusBLEU(references, predictions, opts)
+}
+
+// bleuStats holds the sufficient statistics of BLEU, summed over one or more predictions
+type bleuStats struct {
+	hypothesisLength int
+	referenceLength  int
+	// correct and total count the clipped n-gram matches and the n-grams of the predictions, by order minus one
+	correct []float64
+	total   []float64
+}
+
+func newBLEUStats(maxOrder int) *bleuStats {
+	return &bleuStats{
+		correct: make([]float64, maxOrder),
+		total:   make([]float64, maxOrder),
+	}
+}
+
+// add adds the statistics of a prediction scored against its references
+func (s *bleuStats) add(prediction string, references []string, opts BLEUOptions) {
+	hypothesis := tokenizeBLEU(prediction, opts)
+	hypothesisCounts := countNgrams(hypothesis, opts.MaxOrder)
+
+	// Clip the matches of each n-gram by its largest count in any reference, and take the length
+	// of the reference closest to the prediction, the shortest one on ties
+	maxCounts := make(map[string]int)
+	referenceLength, closestDiff := 0, -1
+	for _, reference := range references {
+		tokens := tokenizeBLEU(reference, opts)
+		diff := len(tokens) - len(hypothesis)
+		if diff < 0 {
+			diff = -diff
+		}
+		if closestDiff < 0 || diff < closestDiff || (diff == closestDiff && len(tokens) < referenceLength) {
+			closestDiff, referenceLength = diff, len(tokens)
+		}
+		for ngram, count := range countNgrams(tokens, opts.MaxOrder) {
+			if count > maxCounts[ngram] {
+				maxCounts[ngram] = count
+			}
+		}
+	}
+
+	s.hypothesisLength += len(hypothesis)
+	s.referenceLength += referenceLength
+	for n := 1; n <= opts.MaxOrder; n++ {
+		if len(hypothesis) >= n {
+			s.total[n-1] += float64(len(hypothesis) - n + 1)
+		}
+	}
+	for ngram, count := range hypothesisCounts {
+		order := strings.Count(ngram, " ") + 1
+		s.correct[order-1] += float64(min(count, maxCounts[ngram]))
+	}
+}
+
+// score computes BLEU from the statistics like SacreBLEU. With effectiveOrder, the precisions are only
+// averaged up to the largest order with any n-gram, as done for sentence-level scores.
+func (s *bleuStats) score(opts BLEUOptions, effectiveOrder bool) BLEUScore {
+	score := BLEUScore{
+		Precisions:       make([]float64, opts.MaxOrder),
+		HypothesisLength: s.hypothesisLength,
+		ReferenceLength:  s.referenceLength,
+		BrevityPenalty:   1,
+	}
+	if s.hypothesisLength < s.referenceLength {
+		score.BrevityPenalty = 0
+		if s.hypothesisLength > 0 {
+			score.BrevityPenalty = math.Exp(1 - float64(s.referenceLength)/float64(s.hypothesisLength))
+		}
+	}
+
+	anyCorrect := false
+	for _, correct := range s.correct {
+		anyCorrect = anyCorrect || correct > 0
+	}
+	if !anyCorrect {
+		return score
+	}
+
+	smoothMteval := 1.0
+	order := opts.MaxOrder
+	for n := 1; n <= opts.MaxOrder; n++ {
+		correct, total := s.correct[n-1], s.total[n-1]
+		if opts.Smoothing == SmoothAddK && n > 1 {
+			correct += opts.SmoothValue
+			total += opts.SmoothValue
+		}
+		if total == 0 {
+			break
+		}
+		if effectiveOrder {
+			order = n
+		}
+
+		switch {
+		case correct > 0:
+			score.Precisions[n-1] = 100 * correct / total
+		case opts.Smoothing == SmoothExp:
+			smoothMteval *= 2
+			score.Precisions[n-1] = 100 / (smoothMteval * total)
+		case opts.Smoothing == SmoothFloor:
+			score.Precisions[n-1] = 100 * opts.SmoothValue / total
+		}
+	}
+
+	logSum := 0.0
+	for _, precision := range score.Precisions[:order] {
+		if precision == 0 {
+			// SacreBLEU takes the log of 0 as a large negative number, turning the score into 0
+			return score
+		}
+		logSum += math.Log(precision)
+	}
+	score.Score = score.BrevityPenalty * math.Exp(logSum/float64(order))
+	return score
+}
+
+// countNgrams counts the n-grams of tokens up to maxOrder, joining their tokens with spaces
+func countNgrams(tokens []string, maxOrder int) map[string]int {
+	counts := make(map[string]int)
+	for n := 1; n <= maxOrder; n++ {
+		for i := 0; i+n <= len(tokens); i++ {
+			counts[strings.Join(tokens[i:i+n], " ")]++
+		}
+	}
+	return counts
+}
+
+// bleuTokenizers maps the tokenizers of BLEU to the regular expressions they apply in order, with their replacement
+var bleuTokenizers = map[BLEUTokenizer][]bleuRule{
+	Tokenize13a: {
+		// Split symbols and punctuation, except periods, commas and dashes
+		{regexp.MustCompile(`([\{-\~\[-\` + "`" + ` -\&\(-\+\:-\@\/])`), " ${1} "},
+		// Split periods and commas unless preceded by a digit
+		{regexp.MustCompile(`([^0-9])([\.,])`), "${1} ${2} "},
+		// Split periods and commas unless followed by a digit
+		{regexp.MustCompile(`([\.,])([^0-9])`), " ${1} ${2}"},
+		// Split dashes preceded by a digit
+		{regexp.MustCompile(`([0-9])(-)`), "${1} ${2} "},
+	},
+	TokenizeIntl: {
+		// Split punctuation unless preceded by a number
+		{regexp.MustCompile(`(\P{N})(\p{P})`), "${1} ${2} "},
+		// Split punctuation unless followed by a number
+		{regexp.MustCompile(`(\p{P})(\P{N})`), " ${1} ${2}"},
+		// Split symbols
+		{regexp.MustCompile(`(\p{S})`), " ${1} "},
+	},
+	TokenizeNone: nil,
+}
+
+type bleuRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// tokenizeBLEU splits text into tokens like SacreBLEU
+func tokenizeBLEU(text string, opts BLEUOptions) []string {
+	if opts.Lowercase {
+		text = strings.ToLower(text)
+	}
+	text = strings.TrimRightFunc(text, unicode.IsSpace)
+
+	switch opts.Tokenizer {
+	case Tokenize13a:
+		text = strings.ReplaceAll(text, "<skipped>", "")
+		text = strings.ReplaceAll(text, "-\n", "")
+		text = strings.ReplaceAll(text, "\n", " ")
+		if strings.Contains(text, "&") {
+			// Unescaped one after the other like SacreBLEU, so "&amp;lt;" becomes "<"
+			text = strings.ReplaceAll(text, "&quot;", `"`)
+			text = strings.ReplaceAll(text, "&amp;", "&")
+			text = strings.ReplaceAll(text, "&lt;", "<")
+			text = strings.ReplaceAll(text, "&gt;", ">")
+		}
+		text = " " + text + " "
+	}
+
+	for _, rule := range bleuTokenizers[opts.Tokenizer] {
+		text = rule.pattern.ReplaceAllString(text, rule.replacement)
+	}
+	return strings.Fields(text)
+}
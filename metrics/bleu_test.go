@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	eval "github.com/snpu/eval-go"
+)
+
+func TestCorpusBLEU(t *testing.T) {
+	// The example of the SacreBLEU README, which reports
+	// BLEU = 48.53 82.4/50.0/45.5/37.5 (BP = 0.943 ratio = 0.944 hyp_len = 17 ref_len = 18)
+	references := [][]string{
+		{"The dog bit the man.", "The dog had bit the man."},
+		{"It was not unexpected.", "No one was surprised."},
+		{"The man bit him first.", "The man had bitten the dog."},
+	}
+	predictions := []string{"The dog bit the man.", "It wasn't surprising.", "The man had just bitten him."}
+
+	score, err := CorpusBLEU(references, predictions, BLEUOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(score.Score-48.53) > 0.005 {
+		t.Errorf("Score = %g, want 48.53", score.Score)
+	}
+	for n, want := range []float64{82.4, 50.0, 45.5, 37.5} {
+		if math.Abs(score.Precisions[n]-want) > 0.05 {
+			t.Errorf("Precisions[%d] = %g, want %g", n, score.Precisions[n], want)
+		}
+	}
+	if math.Abs(score.BrevityPenalty-0.943) > 0.0005 {
+		t.Errorf("BrevityPenalty = %g, want 0.943", score.BrevityPenalty)
+	}
+	if score.HypothesisLength != 17 || score.ReferenceLength != 18 {
+		t.Errorf("lengths = %d, %d, want 17, 18", score.HypothesisLength, score.ReferenceLength)
+	}
+
+	if _, err := CorpusBLEU(references[:1], predictions, BLEUOptions{}); err == nil {
+		t.Error("CorpusBLEU with fewer references than predictions succeeded, want an error")
+	}
+}
+
+func TestSentenceBLEU(t *testing.T) {
+	references := []string{"It was not unexpected.", "No one was surprised."}
+	prediction := "It wasn't surprising."
+
+	// The prediction matches 2 of its 4 unigrams and no higher order n-gram, with a brevity penalty of exp(-1/4)
+	tests := []struct {
+		name string
+		opts BLEUOptions
+		want float64
+	}{
+		{"exp smoothing", BLEUOptions{}, 14.794015674776452},
+		{"no smoothing", BLEUOptions{Smoothing: SmoothNone}, 0},
+		{"floor smoothing", BLEUOptions{Smoothing: SmoothFloor}, 7.440995947486906},
+		{"add-k smoothing", BLEUOptions{Smoothing: SmoothAddK}, 29.588031349552907},
+	}
+	for _, tt := range tests {
+		score, err := SentenceBLEU(references, prediction, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(score.Score-tt.want) > 1e-9 {
+			t.Errorf("%s: Score = %.12g, want %.12g", tt.name, score.Score, tt.want)
+		}
+	}
+
+	// Only the orders the prediction is long enough for are averaged
+	if score, _ := SentenceBLEU([]string{"the cat"}, "The cat", BLEUOptions{Lowercase: true}); math.Abs(score.Score-100) > 1e-9 {
+		t.Errorf("Score of a two-word match = %g, want 100", score.Score)
+	}
+	if score, _ := SentenceBLEU([]string{"a b c d"}, "e f g h", BLEUOptions{}); score.Score != 0 {
+		t.Errorf("Score without matches = %g, want 0", score.Score)
+	}
+	if score, _ := SentenceBLEU([]string{"a b c d"}, "", BLEUOptions{}); score.Score != 0 || score.BrevityPenalty != 0 {
+		t.Errorf("Score of an empty prediction = %g with brevity penalty %g, want 0", score.Score, score.BrevityPenalty)
+	}
+}
+
+func TestBLEUOptions(t *testing.T) {
+	for _, opts := range []BLEUOptions{
+		{MaxOrder: -1},
+		{Smoothing: "laplace"},
+		{Tokenizer: "moses"},
+	} {
+		if _, err := SentenceBLEU([]string{"a"}, "a", opts); err == nil {
+			t.Errorf("SentenceBLEU with %+v succeeded, want an error", opts)
+		}
+	}
+}
+
+func TestTokenizeBLEU(t *testing.T) {
+	tests := []struct {
+		tokenizer BLEUTokenizer
+		text      string
+		want      []string
+	}{
+		{Tokenize13a, "Hello, world. It costs $3.50 or 1,000 co-ops!", []string{"Hello", ",", "world", ".", "It", "costs", "$", "3.50", "or", "1,000", "co-ops", "!"}},
+		{Tokenize13a, "It wasn't 5-6 &quot;ok&quot;\n", []string{"It", "wasn't", "5", "-", "6", "\"", "ok", "\""}},
+		{TokenizeIntl, "Prix: 3,50€ «ok»", []string{"Prix", ":", "3,50", "€", "«", "ok", "»"}},
+		{TokenizeNone, "a, b", []string{"a,", "b"}},
+	}
+	for _, tt := range tests {
+		opts, err := BLEUOptions{Tokenizer: tt.tokenizer}.withDefaults()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tokenizeBLEU(tt.text, opts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenizeBLEU(%q) with %s = %q, want %q", tt.text, tt.tokenizer, got, tt.want)
+		}
+	}
+}
+
+func TestBLEUMetric(t *testing.T) {
+	evaluation := eval.NewPairwiseEvaluation("translation", "", []eval.PairwiseMetric{BLEU(BLEUOptions{})})
+	results, err := evaluation.Run(context.Background(), []eval.Instance{
+		{Reference: "It was not unexpected.", References: []string{"No one was surprised."}, Prediction: "It wasn't surprising."},
+		{Reference: "The dog bit the man.", Prediction: "The dog bit the man."},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// References are scored all at once, not one by one
+	if got := results[0].MetricResults["bleu"]; math.Abs(got-14.794015674776452) > 1e-9 {
+		t.Errorf("bleu = %g, want 14.794", got)
+	}
+	if got := results[1].MetricResults["bleu"]; math.Abs(got-100) > 1e-9 {
+		t.Errorf("bleu = %g, want 100", got)
+	}
+	if got := results[1].Details["bleu"]["hypothesis_length"]; got != 6 {
+		t.Errorf("hypothesis_length = %v, want 6", got)
+	}
+
+	corpus, err := CorpusBLEUResults(results, BLEUOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if corpus.HypothesisLength != 10 {
+		t.Errorf("corpus HypothesisLength = %d, want 10", corpus.HypothesisLength)
+	}
+}
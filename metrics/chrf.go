@@ -0,0 +1,208 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// asciiPunctuation are the ASCII punctuation characters, as in Python's string.punctuation
+const asciiPunctuation = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+// ChrFOptions configures chrF, the zero value matches the defaults of SacreBLEU's chrF
+type ChrFOptions struct {
+	// CharOrder is the largest character n-gram order, 0 means 6
+	CharOrder int
+	// WordOrder is the largest word n-gram order, 0 disables word n-grams and chrF++ uses 2
+	WordOrder int
+	// Beta weighs recall over precision, 0 means 2
+	Beta float64
+	// Whitespace keeps whitespace in character n-grams
+	Whitespace bool
+}
+
+// withDefaults returns the options with their zero values replaced by defaults, or an error for invalid options
+func (o ChrFOptions) withDefaults() (ChrFOptions, error) {
+	if o.CharOrder == 0 {
+		o.CharOrder = 6
+	}
+	if o.Beta == 0 {
+		o.Beta = 2
+	}
+	if o.CharOrder < 0 || o.WordOrder < 0 || o.Beta < 0 {
+		return o, fmt.Errorf("invalid chrF options: character order %d, word order %d, beta %g", o.CharOrder, o.WordOrder, o.Beta)
+	}
+	return o, nil
+}
+
+// ChrF returns a pairwise metric that computes the chrF score of each prediction between 0 and 100, the F-score of
+// the character n-grams of reference and prediction, like SacreBLEU's sentence_chrf. Character n-grams are made of
+// Unicode code points. Predictions with several references are scored against the best matching one.
+func ChrF(opts ChrFOptions) eval.PairwiseMetric {
+	return chrfMetric("chrf", "Computes the chrF score of character n-grams between 0 and 100", opts)
+}
+
+// ChrFPlusPlus returns a pairwise metric that computes the chrF++ score of each prediction between 0 and 100,
+// which adds word unigrams and bigrams to the character n-grams of chrF. A non-zero opts.WordOrder is kept.
+func ChrFPlusPlus(opts ChrFOptions) eval.PairwiseMetric {
+	if opts.WordOrder == 0 {
+		opts.WordOrder = 2
+	}
+	return chrfMetric("chrf++", "Computes the chrF++ score of character and word n-grams between 0 and 100", opts)
+}
+
+// CorpusChrF computes the chrF score of predictions against their references like SacreBLEU's corpus_chrf,
+// from the n-gram statistics of the best matching reference of each prediction summed over every prediction.
+// references[i] holds the references of predictions[i].
+func CorpusChrF(references [][]string, predictions []string, opts ChrFOptions) (float64, error) {
+	if len(references) != len(predictions) {
+		return 0, fmt.Errorf("number of references (%d) does not match number of predictions (%d)",
+			len(references), len(predictions))
+	}
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return 0, err
+	}
+
+	total := make(chrfStats, 3*(opts.CharOrder+opts.WordOrder))
+	for i, prediction := range predictions {
+		stats := bestChrFStats(references[i], prediction, opts)
+		for s := range total {
+			total[s] += stats[s]
+		}
+	}
+	return total.score(opts), nil
+}
+
+func chrfMetric(name, description string, opts ChrFOptions) eval.PairwiseMetric {
+	return eval.NewMultiReferencePairwiseMetric(
+		name,
+		description,
+		func(ctx context.Context, references [][]string, predictions []string) ([]float64, error) {
+			opts, err := opts.withDefaults()
+			if err != nil {
+				return nil, err
+			}
+
+			scores := make([]float64, len(predictions))
+			for i, prediction := range predictions {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				scores[i] = bestChrFStats(references[i], prediction, opts).score(opts)
+			}
+			return scores, nil
+		},
+	)
+}
+
+// chrfStats holds, for every character then word n-gram order, the number of n-grams of the prediction,
+// of the reference and of their matches
+type chrfStats []float64
+
+// bestChrFStats returns the statistics of the prediction against the reference it scores best against,
+// the first one on ties
+func bestChrFStats(references []string, prediction string, opts ChrFOptions) chrfStats {
+	predictionNgrams := chrfNgrams(prediction, opts)
+
+	best := make(chrfStats, 3*len(predictionNgrams))
+	bestScore := -1.0
+	for _, reference := range references {
+		referenceNgrams := chrfNgrams(reference, opts)
+		stats := make(chrfStats, 0, 3*len(predictionNgrams))
+		for n, counts := range predictionNgrams {
+			predictionTotal, referenceTotal, matches := 0, 0, 0
+			for ngram, count := range counts {
+				predictionTotal += count
+				matches += min(count, referenceNgrams[n][ngram])
+			}
+			for _, count := range referenceNgrams[n] {
+				referenceTotal += count
+			}
+			stats = append(stats, float64(predictionTotal), float64(referenceTotal), float64(matches))
+		}
+		if score := stats.score(opts); score > bestScore {
+			best, bestScore = stats, score
+		}
+	}
+	return best
+}
+
+// score computes the chrF score from the statistics like SacreBLEU, averaging the precisions and recalls
+// of the orders found on both sides before combining them
+func (s chrfStats) score(opts ChrFOptions) float64 {
+	const eps = 1e-16
+	factor := opts.Beta * opts.Beta
+
+	precision, recall := 0.0, 0.0
+	effectiveOrder := 0
+	for i := 0; i+2 < len(s); i += 3 {
+		predictionTotal, referenceTotal, matches := s[i], s[i+1], s[i+2]
+		orderPrecision, orderRecall := eps, eps
+		if predictionTotal > 0 {
+			orderPrecision = matches / predictionTotal
+		}
+		if referenceTotal > 0 {
+			orderRecall = matches / referenceTotal
+		}
+		if predictionTotal > 0 && referenceTotal > 0 {
+			effectiveOrder++
+		}
+		precision += orderPrecision
+		recall += orderRecall
+	}
+	if effectiveOrder == 0 {
+		return 0
+	}
+	precision /= float64(effectiveOrder)
+	recall /= float64(effectiveOrder)
+
+	if precision+recall == 0 {
+		return 0
+	}
+	return 100 * (1 + factor) * precision * recall / (factor*precision + recall)
+}
+
+// chrfNgrams counts the character n-grams of text followed by its word n-grams, by order
+func chrfNgrams(text string, opts ChrFOptions) []map[string]int {
+	ngrams := make([]map[string]int, 0, opts.CharOrder+opts.WordOrder)
+
+	characters := []rune(text)
+	if !opts.Whitespace {
+		characters = []rune(strings.Join(strings.Fields(text), ""))
+	}
+	for n := 1; n <= opts.CharOrder; n++ {
+		counts := make(map[string]int)
+		for i := 0; i+n <= len(characters); i++ {
+			counts[string(characters[i:i+n])]++
+		}
+		ngrams = append(ngrams, counts)
+	}
+
+	words := chrfWords(text)
+	for n := 1; n <= opts.WordOrder; n++ {
+		ngrams = append(ngrams, countRougeNgrams(words, n))
+	}
+	return ngrams
+}
+
+// chrfWords splits text on whitespace, separating a punctuation character from the end or else the start of words
+func chrfWords(text string) []string {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		switch {
+		case len(runes) == 1:
+			words = append(words, word)
+		case strings.ContainsRune(asciiPunctuation, runes[len(runes)-1]):
+			words = append(words, string(runes[:len(runes)-1]), string(runes[len(runes)-1]))
+		case strings.ContainsRune(asciiPunctuation, runes[0]):
+			words = append(words, string(runes[0]), string(runes[1:]))
+		default:
+			words = append(words, word)
+		}
+	}
+	return words
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCorpusChrF(t *testing.T) {
+	// The example of the SacreBLEU README, which reports chrF2 = 59.73
+	references := [][]string{
+		{"The dog bit the man.", "The dog had bit the man."},
+		{"It was not unexpected.", "No one was surprised."},
+		{"The man bit him first.", "The man had bitten the dog."},
+	}
+	predictions := []string{"The dog bit the man.", "It wasn't surprising.", "The man had just bitten him."}
+
+	score, err := CorpusChrF(references, predictions, ChrFOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(score-59.73) > 0.005 {
+		t.Errorf("CorpusChrF = %g, want 59.73", score)
+	}
+
+	if _, err := CorpusChrF(references, predictions, ChrFOptions{Beta: -1}); err == nil {
+		t.Error("CorpusChrF with a negative beta succeeded, want an error")
+	}
+}
+
+func TestChrF(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       ChrFOptions
+		references []string
+		prediction string
+		want       float64
+	}{
+		{"identical", ChrFOptions{}, []string{"The cat sat."}, "The cat sat.", 100},
+		{"disjoint", ChrFOptions{}, []string{"abc"}, "xyz", 0},
+		{"empty prediction", ChrFOptions{}, []string{"abc"}, "", 0},
+		// Orders 1 to 3 match 2/3, 1/2 and 0 of the n-grams of both sides, longer orders are absent
+		{"partial match", ChrFOptions{}, []string{"abc"}, "abd", 700.0 / 18},
+		// Whitespace is removed from character n-grams by default
+		{"whitespace", ChrFOptions{}, []string{"a b c"}, "abc", 100},
+		// Unigrams have a precision of 1 and a recall of 2/3, bigrams match none of "a " and " b"
+		{"kept whitespace", ChrFOptions{Whitespace: true}, []string{"a b"}, "ab", 500.0 / 14},
+		// Characters and word unigrams match 1/2 of the n-grams of both sides, word bigrams none
+		{"word n-grams", ChrFOptions{CharOrder: 1, WordOrder: 2}, []string{"a b"}, "a c", 100.0 / 3},
+		// The best matching reference is used
+		{"several references", ChrFOptions{}, []string{"xyz", "abc"}, "abc", 100},
+	}
+	for _, tt := range tests {
+		opts, err := tt.opts.withDefaults()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := bestChrFStats(tt.references, tt.prediction, opts).score(opts); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: chrF = %.12g, want %.12g", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestChrFMetrics(t *testing.T) {
+	chrf := ChrF(ChrFOptions{})
+	chrfPlusPlus := ChrFPlusPlus(ChrFOptions{})
+	if chrf.Name != "chrf" || chrfPlusPlus.Name != "chrf++" {
+		t.Errorf("names = %s, %s", chrf.Name, chrfPlusPlus.Name)
+	}
+
+	references := [][]string{{"It was not unexpected.", "No one was surprised."}}
+	predictions := []string{"It wasn't surprising."}
+	chrfScores, err := chrf.ComputeMultiReference(context.Background(), references, predictions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chrfPlusPlusScores, err := chrfPlusPlus.ComputeMultiReference(context.Background(), references, predictions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Few words match, so word n-grams lower the score
+	if !(chrfPlusPlusScores[0] < chrfScores[0]) {
+		t.Errorf("chrF++ = %g, want less than chrF = %g", chrfPlusPlusScores[0], chrfScores[0])
+	}
+}
+
+func TestChrFWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello, world.", []string{"Hello", ",", "world", "."}},
+		{"(a b) .", []string{"(", "a", "b", ")", "."}},
+		{"it's", []string{"it's"}},
+	}
+	for _, tt := range tests {
+		if got := chrfWords(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("chrfWords(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
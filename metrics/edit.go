@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"context"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// Levenshtein returns a pairwise metric that computes the Levenshtein distance between reference and prediction,
+// the number of inserted, deleted or substituted Unicode code points turning one into the other
+func Levenshtein() eval.PairwiseMetric {
+	return editMetric("levenshtein", "Computes the number of character edits between two strings", func(a, b []rune) float64 {
+		return float64(levenshtein(a, b, false))
+	})
+}
+
+// DamerauLevenshtein returns a pairwise metric that computes the Damerau-Levenshtein distance between reference
+// and prediction, which also counts the transposition of two adjacent code points as a single edit. It computes
+// the optimal string alignment distance, which never edits a substring more than once.
+func DamerauLevenshtein() eval.PairwiseMetric {
+	return editMetric("damerau_levenshtein", "Computes the number of character edits and transpositions between two strings", func(a, b []rune) float64 {
+		return float64(levenshtein(a, b, true))
+	})
+}
+
+// EditSimilarity returns a pairwise metric that computes one minus the Levenshtein distance between reference and
+// prediction divided by the length of the longer one, from 0 for unrelated strings to 1 for equal strings
+func EditSimilarity() eval.PairwiseMetric {
+	return editMetric("edit_similarity", "Computes one minus the normalized character edit distance between two strings", func(a, b []rune) float64 {
+		longest := max(len(a), len(b))
+		if longest == 0 {
+			return 1
+		}
+		return 1 - float64(levenshtein(a, b, false))/float64(longest)
+	})
+}
+
+// LevenshteinDistance returns the number of inserted, deleted or substituted code points turning a into b
+func LevenshteinDistance(a, b string) int {
+	return levenshtein([]rune(a), []rune(b), false)
+}
+
+// DamerauLevenshteinDistance returns the optimal string alignment distance between a and b, which counts
+// inserted, deleted or substituted code points and transpositions of two adjacent ones
+func DamerauLevenshteinDistance(a, b string) int {
+	return levenshtein([]rune(a), []rune(b), true)
+}
+
+// editMetric creates a pairwise metric scoring the code points of each reference-prediction pair with distance
+func editMetric(name, description string, distance func(a, b []rune) float64) eval.PairwiseMetric {
+	return eval.NewPairwiseMetric(
+		name,
+		description,
+		func(ctx context.Context, references, predictions []string) ([]float64, error) {
+			scores := make([]float64, len(references))
+			for i := range references {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				scores[i] = distance([]rune(references[i]), []rune(predictions[i]))
+			}
+			return scores, nil
+		},
+	)
+}
+
+// levenshtein computes the edit distance between a and b, counting transpositions of adjacent code points
+// as single edits when transpositions is set. Common prefixes and suffixes are skipped and only three rows of
+// the distance matrix are kept, so long texts take time proportional to the product of their differing parts
+// and memory proportional to the shorter one.
+func levenshtein(a, b []rune, transpositions bool) int {
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	if len(b) == 0 {
+		return len(a)
+	}
+
+	// previous, current and next hold the distances of a[:i-1], a[:i] and a[:i+1] to every prefix of b
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	next := make([]int, len(b)+1)
+	for j := range current {
+		current[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		next[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			next[j] = min(current[j]+1, next[j-1]+1, current[j-1]+cost)
+			if transpositions && i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				next[j] = min(next[j], previous[j-2]+1)
+			}
+		}
+		previous, current, next = current, next, previous
+	}
+	return current[len(b)]
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestLevenshteinDistance(t *testing.T) {
+	tests := []struct {
+		a, b        string
+		levenshtein int
+		damerau     int
+	}{
+		{"", "", 0, 0},
+		{"", "abc", 3, 3},
+		{"kitten", "sitting", 3, 3},
+		{"flaw", "lawn", 2, 2},
+		{"ab", "ba", 2, 1},
+		{"abcdef", "abdcef", 2, 1},
+		// The optimal string alignment distance does not edit the transposed characters again
+		{"ca", "abc", 3, 3},
+		{"café", "cafe", 1, 1},
+		{"日本語", "日本", 1, 1},
+	}
+	for _, tt := range tests {
+		if got := LevenshteinDistance(tt.a, tt.b); got != tt.levenshtein {
+			t.Errorf("LevenshteinDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.levenshtein)
+		}
+		if got := LevenshteinDistance(tt.b, tt.a); got != tt.levenshtein {
+			t.Errorf("LevenshteinDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.levenshtein)
+		}
+		if got := DamerauLevenshteinDistance(tt.a, tt.b); got != tt.damerau {
+			t.Errorf("DamerauLevenshteinDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.damerau)
+		}
+	}
+}
+
+// fullLevenshtein computes the edit distance with the whole distance matrix, as a reference implementation
+func fullLevenshtein(a, b []rune, transpositions bool) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if transpositions && i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func TestLevenshteinMatchesFullMatrix(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomText := func() []rune {
+		text := make([]rune, random.Intn(12))
+		for i := range text {
+			text[i] = []rune("abcé")[random.Intn(4)]
+		}
+		return text
+	}
+	for i := 0; i < 2000; i++ {
+		a, b := randomText(), randomText()
+		for _, transpositions := range []bool{false, true} {
+			if got, want := levenshtein(a, b, transpositions), fullLevenshtein(a, b, transpositions); got != want {
+				t.Fatalf("levenshtein(%q, %q, %v) = %d, want %d", string(a), string(b), transpositions, got, want)
+			}
+		}
+	}
+}
+
+func TestEditMetrics(t *testing.T) {
+	references := []string{"kitten", "", "ab"}
+	predictions := []string{"sitting", "", "ba"}
+	tests := []struct {
+		name    string
+		compute func() ([]float64, error)
+		want    []float64
+	}{
+		{"levenshtein", func() ([]float64, error) {
+			metric := Levenshtein()
+			return metric.Compute(context.Background(), references, predictions)
+		}, []float64{3, 0, 2}},
+		{"damerau_levenshtein", func() ([]float64, error) {
+			metric := DamerauLevenshtein()
+			return metric.Compute(context.Background(), references, predictions)
+		}, []float64{3, 0, 1}},
+		{"edit_similarity", func() ([]float64, error) {
+			metric := EditSimilarity()
+			return metric.Compute(context.Background(), references, predictions)
+		}, []float64{1 - 3.0/7, 1, 0}},
+	}
+	for _, tt := range tests {
+		scores, err := tt.compute()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, want := range tt.want {
+			if math.Abs(scores[i]-want) > 1e-12 {
+				t.Errorf("%s of %q and %q = %g, want %g", tt.name, references[i], predictions[i], scores[i], want)
+			}
+		}
+	}
+}
@@ -2,8 +2,8 @@ package metrics
 
 import (
 	"context"
+	"sort"
 	"strings"
-	"unicode"
 
 	"github.com/snpu/eval-go"
 )
@@ -48,8 +48,15 @@ func LengthRatio() eval.PairwiseMetric {
 	)
 }
 
-// WordOverlap returns a pairwise metric that computes Jaccard similarity between words in two strings
+// WordOverlap returns a pairwise metric that computes Jaccard similarity between words in two strings.
+// Its details list the shared words.
 func WordOverlap() eval.PairwiseMetric {
+	return WordOverlapWith(DefaultTokenizer)
+}
+
+// WordOverlapWith returns the word_overlap metric splitting strings into words with tokenize,
+// e.g. the Tokenize method of a TextPipeline
+func WordOverlapWith(tokenize Tokenizer) eval.PairwiseMetric {
 	return eval.NewPairwiseMetric(
 		"word_overlap",
 		"Computes Jaccard similarity between words in two strings",
@@ -57,8 +64,8 @@ func WordOverlap() eval.PairwiseMetric {
 			scores := make([]float64, len(references))
 			for i := range references {
 				// Split strings into words
-				refWords := splitIntoWords(references[i])
-				predWords := splitIntoWords(predictions[i])
+				refWords := tokenize(references[i])
+				predWords := tokenize(predictions[i])
 				
 				if len(refWords) == 0 && len(predWords) == 0 {
 					scores[i] = 1.0
@@ -78,12 +85,15 @@ func WordOverlap() eval.PairwiseMetric {
 					}
 					
 					// Count intersection
-					intersection := 0
+					shared := []string{}
 					for word := range refMap {
 						if predMap[word] {
-							intersection++
+							shared = append(shared, word)
 						}
 					}
+					intersection := len(shared)
+					sort.Strings(shared)
+					eval.AttachDetails(ctx, i, eval.Details{"shared_words": shared})
 					
 					// Count union
 					union := len(refMap) + len(predMap) - intersection
@@ -98,13 +108,15 @@ func WordOverlap() eval.PairwiseMetric {
 
 // KeywordPresence returns a pointwise metric that checks if text contains specific keywords
 func KeywordPresence() eval.PointwiseMetric {
+	return KeywordPresenceFor(defaultKeywords)
+}
+
+// KeywordPresenceFor returns a pointwise metric that computes the fraction of the given keywords contained in the text
+func KeywordPresenceFor(keywords []string) eval.PointwiseMetric {
 	return eval.NewPointwiseMetric(
 		"keyword_presence",
 		"Checks if text contains specific keywords",
 		func(ctx context.Context, predictions []string) ([]float64, error) {
-			// Example keywords - these should be configurable in practice
-			keywords := []string{"important", "critical", "significant"}
-			
 			if len(keywords) == 0 {
 				scores := make([]float64, len(predictions))
 				return scores, nil
@@ -124,29 +136,3 @@ func KeywordPresence() eval.PointwiseMetric {
 		},
 	)
 }
-
-// splitIntoWords splits a string into words, removing punctuation and converting to lowercase
-func splitIntoWords(text string) []string {
-	// Convert to lowercase
-	text = strings.ToLower(text)
-	
-	// Replace punctuation with spaces
-	for _, char := range text {
-		if unicode.IsPunct(char) {
-			text = strings.ReplaceAll(text, string(char), " ")
-		}
-	}
-	
-	// Split by whitespace
-	words := strings.Fields(text)
-	
-	// Remove empty strings
-	result := make([]string, 0, len(words))
-	for _, word := range words {
-		if word != "" {
-			result = append(result, word)
-		}
-	}
-	
-	return result
-}
@@ -0,0 +1,270 @@
+package metrics
+
+import "strings"
+
+// PorterStem reduces a lowercase English word to its stem with the Porter algorithm, e.g. "running" to "run"
+// and "generalization" to "gener". It follows the default mode of NLTK's PorterStemmer used by rouge-score,
+// which extends the original algorithm, e.g. "ties" and "dying" stem to "tie" and "die" rather than "ti" and
+// "dy". Words of two letters or less and words with characters other than lowercase ASCII letters are
+// returned unchanged.
+func PorterStem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+	if stem, ok := porterIrregular[word]; ok {
+		return stem
+	}
+
+	s := porterStemmer{b: []byte(word)}
+	s.step1a()
+	s.step1b()
+	s.step1c()
+	s.step2()
+	s.replaceLongest(porterStep3, 0)
+	s.step4()
+	s.step5()
+	return string(s.b)
+}
+
+// porterRule replaces a suffix of a word by another when the measure of the remaining stem is large enough
+type porterRule struct {
+	suffix, replacement string
+}
+
+// porterIrregular maps the irregular forms NLTK stems from a fixed list to their stem
+var porterIrregular = map[string]string{
+	"sky": "sky", "skies": "sky", "dying": "die", "lying": "lie", "tying": "tie", "news": "news",
+	"innings": "inning", "inning": "inning", "outings": "outing", "outing": "outing",
+	"cannings": "canning", "canning": "canning", "howe": "howe",
+	"proceed": "proceed", "exceed": "exceed", "succeed": "succeed",
+}
+
+// porterStep2 holds the rules of step 2 as extended by NLTK: BLI replaces ABLI and FULLI is added,
+// ALLI and LOGI are handled apart by step2
+var porterStep2 = []porterRule{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"}, {"izer", "ize"},
+	{"bli", "ble"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"},
+	{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"},
+	{"fulness", "ful"}, {"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	{"fulli", "ful"},
+}
+
+var porterStep3 = []porterRule{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"}, {"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+var porterStep4 = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment", "ent",
+	"ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+type porterStemmer struct {
+	b []byte
+}
+
+// consonant reports whether the letter at i is a consonant, y being one unless it follows a consonant
+func (s *porterStemmer) consonant(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.consonant(i-1)
+	}
+	return true
+}
+
+// measure counts the vowel-consonant sequences of the first n letters
+func (s *porterStemmer) measure(n int) int {
+	m, i := 0, 0
+	for i < n && s.consonant(i) {
+		i++
+	}
+	for i < n {
+		for i < n && !s.consonant(i) {
+			i++
+		}
+		if i == n {
+			break
+		}
+		for i < n && s.consonant(i) {
+			i++
+		}
+		m++
+	}
+	return m
+}
+
+// hasVowel reports whether the first n letters contain a vowel
+func (s *porterStemmer) hasVowel(n int) bool {
+	for i := 0; i < n; i++ {
+		if !s.consonant(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doubleConsonant reports whether the first n letters end with a double consonant
+func (s *porterStemmer) doubleConsonant(n int) bool {
+	return n >= 2 && s.b[n-1] == s.b[n-2] && s.consonant(n-1)
+}
+
+// cvc reports whether the first n letters end with consonant-vowel-consonant, the last one not being w, x or y.
+// NLTK also accepts two letters made of a vowel and a consonant, so that "using" stems to "use".
+func (s *porterStemmer) cvc(n int) bool {
+	if n == 2 {
+		return !s.consonant(0) && s.consonant(1)
+	}
+	if n < 3 || !s.consonant(n-3) || s.consonant(n-2) || !s.consonant(n-1) {
+		return false
+	}
+	last := s.b[n-1]
+	return last != 'w' && last != 'x' && last != 'y'
+}
+
+func (s *porterStemmer) hasSuffix(suffix string) bool {
+	return strings.HasSuffix(string(s.b), suffix)
+}
+
+// stem returns the length of the word without suffix
+func (s *porterStemmer) stem(suffix string) int {
+	return len(s.b) - len(suffix)
+}
+
+func (s *porterStemmer) replace(suffix, replacement string) {
+	s.b = append(s.b[:s.stem(suffix)], replacement...)
+}
+
+func (s *porterStemmer) step1a() {
+	switch {
+	case len(s.b) == 4 && s.hasSuffix("ies"):
+		// NLTK keeps the e of four-letter words, so that "dies" stems to "die" but "flies" to "fli"
+		s.replace("ies", "ie")
+	case s.hasSuffix("sses"):
+		s.replace("sses", "ss")
+	case s.hasSuffix("ies"):
+		s.replace("ies", "i")
+	case s.hasSuffix("ss"):
+	case s.hasSuffix("s"):
+		s.replace("s", "")
+	}
+}
+
+func (s *porterStemmer) step1b() {
+	// NLTK stems "died" to "die" but "spied" to "spi", skipping the rest of the step
+	if s.hasSuffix("ied") {
+		if len(s.b) == 4 {
+			s.replace("ied", "ie")
+		} else {
+			s.replace("ied", "i")
+		}
+		return
+	}
+
+	if s.hasSuffix("eed") {
+		if s.measure(s.stem("eed")) > 0 {
+			s.replace("eed", "ee")
+		}
+		return
+	}
+
+	removed := false
+	for _, suffix := range []string{"ed", "ing"} {
+		if s.hasSuffix(suffix) && s.hasVowel(s.stem(suffix)) {
+			s.replace(suffix, "")
+			removed = true
+			break
+		}
+	}
+	if !removed {
+		return
+	}
+
+	n := len(s.b)
+	switch {
+	case s.hasSuffix("at"), s.hasSuffix("bl"), s.hasSuffix("iz"):
+		s.b = append(s.b, 'e')
+	case s.doubleConsonant(n) && s.b[n-1] != 'l' && s.b[n-1] != 's' && s.b[n-1] != 'z':
+		s.b = s.b[:n-1]
+	case s.measure(n) == 1 && s.cvc(n):
+		s.b = append(s.b, 'e')
+	}
+}
+
+// step1c replaces a final y by i after a consonant that is not the first letter, as NLTK does instead of
+// requiring a vowel in the stem, so that "enjoy" is kept and "spy" stems to "spi" like "spied"
+func (s *porterStemmer) step1c() {
+	if n := s.stem("y"); s.hasSuffix("y") && n > 1 && s.consonant(n-1) {
+		s.b[len(s.b)-1] = 'i'
+	}
+}
+
+// step2 applies NLTK's step 2, which replaces ALLI by AL before the other rules and runs the result through
+// the step again, and counts the l of LOGI with the stem, so that short stems like "geo" are stemmed too
+func (s *porterStemmer) step2() {
+	switch {
+	case s.hasSuffix("alli"):
+		if s.measure(s.stem("alli")) > 0 {
+			s.replace("alli", "al")
+			s.step2()
+		}
+	case s.hasSuffix("logi"):
+		if s.measure(s.stem("ogi")) > 0 {
+			s.replace("logi", "log")
+		}
+	default:
+		s.replaceLongest(porterStep2, 0)
+	}
+}
+
+// replaceLongest applies the rule with the longest matching suffix when the measure of its stem exceeds minMeasure
+func (s *porterStemmer) replaceLongest(rules []porterRule, minMeasure int) {
+	longest := -1
+	for i, rule := range rules {
+		if s.hasSuffix(rule.suffix) && (longest < 0 || len(rule.suffix) > len(rules[longest].suffix)) {
+			longest = i
+		}
+	}
+	if longest >= 0 && s.measure(s.stem(rules[longest].suffix)) > minMeasure {
+		s.replace(rules[longest].suffix, rules[longest].replacement)
+	}
+}
+
+func (s *porterStemmer) step4() {
+	longest := ""
+	for _, suffix := range porterStep4 {
+		if s.hasSuffix(suffix) && len(suffix) > len(longest) {
+			longest = suffix
+		}
+	}
+	if longest == "" {
+		return
+	}
+
+	n := s.stem(longest)
+	if longest == "ion" && (n == 0 || (s.b[n-1] != 's' && s.b[n-1] != 't')) {
+		return
+	}
+	if s.measure(n) > 1 {
+		s.b = s.b[:n]
+	}
+}
+
+func (s *porterStemmer) step5() {
+	if s.hasSuffix("e") {
+		n := s.stem("e")
+		if m := s.measure(n); m > 1 || (m == 1 && !s.cvc(n)) {
+			s.b = s.b[:n]
+		}
+	}
+
+	n := len(s.b)
+	if s.b[n-1] == 'l' && s.doubleConsonant(n) && s.measure(n) > 1 {
+		s.b = s.b[:n-1]
+	}
+}
@@ -0,0 +1,127 @@
+package metrics
+
+import "testing"
+
+func TestPorterStem(t *testing.T) {
+	// Pairs from the vocabulary and output files published with the algorithm, on which NLTK agrees
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"caress", "caress"},
+		{"cats", "cat"},
+		{"feed", "feed"},
+		{"agreed", "agre"},
+		{"plastered", "plaster"},
+		{"bled", "bled"},
+		{"motoring", "motor"},
+		{"sing", "sing"},
+		{"conflated", "conflat"},
+		{"troubled", "troubl"},
+		{"sized", "size"},
+		{"hopping", "hop"},
+		{"tanned", "tan"},
+		{"falling", "fall"},
+		{"hissing", "hiss"},
+		{"fizzed", "fizz"},
+		{"failing", "fail"},
+		{"filing", "file"},
+		{"happy", "happi"},
+		{"sky", "sky"},
+		{"relational", "relat"},
+		{"conditional", "condit"},
+		{"rational", "ration"},
+		{"valenci", "valenc"},
+		{"hesitanci", "hesit"},
+		{"digitizer", "digit"},
+		{"conformabli", "conform"},
+		{"radicalli", "radic"},
+		{"differentli", "differ"},
+		{"vileli", "vile"},
+		{"analogousli", "analog"},
+		{"vietnamization", "vietnam"},
+		{"predication", "predic"},
+		{"operator", "oper"},
+		{"feudalism", "feudal"},
+		{"decisiveness", "decis"},
+		{"hopefulness", "hope"},
+		{"callousness", "callous"},
+		{"formaliti", "formal"},
+		{"sensitiviti", "sensit"},
+		{"sensibiliti", "sensibl"},
+		{"triplicate", "triplic"},
+		{"formative", "form"},
+		{"formalize", "formal"},
+		{"electriciti", "electr"},
+		{"electrical", "electr"},
+		{"hopeful", "hope"},
+		{"goodness", "good"},
+		{"revival", "reviv"},
+		{"allowance", "allow"},
+		{"inference", "infer"},
+		{"airliner", "airlin"},
+		{"gyroscopic", "gyroscop"},
+		{"adjustable", "adjust"},
+		{"defensible", "defens"},
+		{"irritant", "irrit"},
+		{"replacement", "replac"},
+		{"adjustment", "adjust"},
+		{"dependent", "depend"},
+		{"adoption", "adopt"},
+		{"communism", "commun"},
+		{"activate", "activ"},
+		{"angulariti", "angular"},
+		{"homologous", "homolog"},
+		{"effective", "effect"},
+		{"bowdlerize", "bowdler"},
+		{"probate", "probat"},
+		{"rate", "rate"},
+		{"cease", "ceas"},
+		{"controlling", "control"},
+		{"roll", "roll"},
+		{"generalizations", "gener"},
+		{"oscillators", "oscil"},
+		{"a", "a"},
+		{"is", "is"},
+	}
+	for _, tt := range tests {
+		if got := PorterStem(tt.word); got != tt.want {
+			t.Errorf("PorterStem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestPorterStemNLTKExtensions(t *testing.T) {
+	// Pairs on which NLTK's default mode, used by rouge-score, departs from the original algorithm,
+	// which gives the stem in the comment
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"ties", "tie"},            // ti
+		{"dies", "die"},            // di
+		{"flies", "fli"},           // fli
+		{"died", "die"},            // di
+		{"spied", "spi"},           // spi
+		{"dying", "die"},           // dy
+		{"lying", "lie"},           // ly
+		{"skies", "sky"},           // ski
+		{"news", "news"},           // new
+		{"innings", "inning"},      // in
+		{"proceed", "proceed"},     // proce
+		{"enjoy", "enjoy"},         // enjoi
+		{"spy", "spi"},             // spy
+		{"using", "use"},           // us
+		{"ore", "ore"},             // or
+		{"hopefulli", "hope"},      // hopefulli
+		{"geologi", "geolog"},      // geologi
+		{"conformabli", "conform"}, // conform
+	}
+	for _, tt := range tests {
+		if got := PorterStem(tt.word); got != tt.want {
+			t.Errorf("PorterStem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
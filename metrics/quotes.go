@@ -24,7 +24,8 @@ var postIdRegex = regexp.MustCompile(`https://www\.reddit\.com/r/.*?/comments/([
 // Format: https://www.reddit.com/r/subreddit/...
 var subredditRegex = regexp.MustCompile(`https://www\.reddit\.com/r/([a-zA-Z0-9_]+)/`)
 
-// QuotesCount returns a pointwise metric that counts the number of Reddit user quotes in markdown format
+// QuotesCount returns a pointwise metric that counts the number of Reddit user quotes in markdown format.
+// Its details list the quoted excerpts.
 func QuotesCount() eval.PointwiseMetric {
 	return eval.NewPointwiseMetric(
 		"quotes_count",
@@ -33,8 +34,14 @@ func QuotesCount() eval.PointwiseMetric {
 			scores := make([]float64, len(predictions))
 			
 			for i, prediction := range predictions {
-				matches := redditQuoteRegex.FindAllString(prediction, -1)
+				matches := redditQuoteRegex.FindAllStringSubmatch(prediction, -1)
 				scores[i] = float64(len(matches))
+				
+				quotes := make([]string, len(matches))
+				for j, match := range matches {
+					quotes[j] = match[1]
+				}
+				eval.AttachDetails(ctx, i, eval.Details{"quotes": quotes})
 			}
 			
 			return scores, nil
@@ -65,7 +72,8 @@ func QuotesRatio() eval.PointwiseMetric {
 	)
 }
 
-// QuotesPresence returns a pointwise metric that checks if there is at least one Reddit user quote in the text
+// QuotesPresence returns a pointwise metric that checks if there is at least one Reddit user quote in the text.
+// Run summaries report the rate of texts containing a quote.
 func QuotesPresence() eval.PointwiseMetric {
 	return eval.NewPointwiseMetric(
 		"quotes_presence",
@@ -83,10 +91,11 @@ func QuotesPresence() eval.PointwiseMetric {
 			
 			return scores, nil
 		},
-	)
+	).WithAggregator(eval.RateAggregator)
 }
 
-// QuotesSize returns a pointwise metric that calculates the total size of quoted text in characters
+// QuotesSize returns a pointwise metric that calculates the total size of quoted text in characters.
+// Run summaries report the total size over all texts.
 func QuotesSize() eval.PointwiseMetric {
 	return eval.NewPointwiseMetric(
 		"quotes_size",
@@ -107,7 +116,36 @@ func QuotesSize() eval.PointwiseMetric {
 			
 			return scores, nil
 		},
-	)
+	).WithAggregator(eval.SumAggregator)
+}
+
+// QuoteStats returns a pointwise metric that reports the count, total size in characters and presence of
+// Reddit user quotes from a single pass over each text, as quote_stats.count, quote_stats.size and quote_stats.presence.
+// Sizes are summarized with their sum and presence with its rate, as with QuotesSize and QuotesPresence.
+func QuoteStats() eval.PointwiseMetric {
+	return eval.NewMultiOutputPointwiseMetric(
+		"quote_stats",
+		"Reports the count, total size and presence of Reddit user quotes in the text",
+		[]string{"count", "size", "presence"},
+		func(ctx context.Context, predictions []string) (map[string][]float64, error) {
+			counts := make([]float64, len(predictions))
+			sizes := make([]float64, len(predictions))
+			presence := make([]float64, len(predictions))
+
+			for i, prediction := range predictions {
+				matches := redditQuoteRegex.FindAllStringSubmatch(prediction, -1)
+				for _, match := range matches {
+					sizes[i] += float64(len(match[1]))
+				}
+				counts[i] = float64(len(matches))
+				if len(matches) > 0 {
+					presence[i] = 1.0
+				}
+			}
+
+			return map[string][]float64{"count": counts, "size": sizes, "presence": presence}, nil
+		},
+	).WithOutputAggregator("size", eval.SumAggregator).WithOutputAggregator("presence", eval.RateAggregator)
 }
 
 // ShortQuotesCount returns a pointwise metric that counts the number of quotes with fewer words than the specified threshold
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQuoteStats(t *testing.T) {
+	metric := QuoteStats()
+	scores, err := metric.ComputeOutputs(context.Background(), []string{
+		"As [said here](https://www.reddit.com/r/go/1) and [there](https://www.reddit.com/r/go/2).",
+		"[not reddit](https://example.com/1)",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]float64{
+		"count":    {2, 0},
+		"size":     {14, 0},
+		"presence": {1, 0},
+	}
+	for output, values := range want {
+		for i, value := range values {
+			if scores[output][i] != value {
+				t.Errorf("%s of prediction %d = %g, want %g", output, i, scores[output][i], value)
+			}
+		}
+	}
+
+	// Outputs are summarized like the single-output quote metrics
+	for output, want := range map[string]string{"count": "mean", "size": "sum", "presence": "rate"} {
+		if got := metric.OutputAggregator(output).Name; got != want && !(got == "" && want == "mean") {
+			t.Errorf("%s is summarized with %q, want %s", output, got, want)
+		}
+	}
+}
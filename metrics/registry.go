@@ -0,0 +1,178 @@
+package metrics
+
+import eval "github.com/snpu/eval-go"
+
+// defaultKeywords are the keywords checked by KeywordPresence
+var defaultKeywords = []string{"important", "critical", "significant"}
+
+// Register the built-in metrics in eval.DefaultRegistry under the names they report
+func init() {
+	for _, newMetric := range []func() eval.PairwiseMetric{
+		StringSimilarity,
+		LengthRatio,
+		Levenshtein,
+		DamerauLevenshtein,
+		EditSimilarity,
+		SQuAD,
+	} {
+		eval.Register(pairwiseFactory(newMetric))
+	}
+
+	for _, newMetric := range []func() eval.PointwiseMetric{
+		QuotesCount,
+		QuotesRatio,
+		QuotesPresence,
+		QuotesSize,
+		QuoteStats,
+		ExternalLinksCount,
+		QuoteDiversity,
+		PostDiversity,
+		SubredditDiversity,
+	} {
+		eval.Register(pointwiseFactory(newMetric))
+	}
+
+	eval.Register(eval.MetricFactory{
+		Name:        "keyword_presence",
+		Description: "Computes the fraction of the given keywords contained in the text",
+		Params: []eval.ParamSpec{
+			{Name: "keywords", Type: eval.StringListParam, Description: "keywords to look for", Default: defaultKeywords},
+		},
+		NewPointwise: func(params eval.Params) (eval.PointwiseMetric, error) {
+			return KeywordPresenceFor(params.Strings("keywords")), nil
+		},
+	})
+	eval.Register(eval.MetricFactory{
+		Name:        "short_quotes_count",
+		Description: "Counts the number of quotes with fewer words than the threshold",
+		Params: []eval.ParamSpec{
+			{Name: "threshold", Type: eval.IntParam, Description: "number of words below which a quote is short", Default: 5},
+		},
+		NewPointwise: func(params eval.Params) (eval.PointwiseMetric, error) {
+			return ShortQuotesCount(params.Int("threshold")), nil
+		},
+	})
+	eval.Register(eval.MetricFactory{
+		Name:        "word_overlap",
+		Description: "Computes Jaccard similarity between words in two strings",
+		Params: []eval.ParamSpec{
+			{Name: "nfkc", Type: eval.BoolParam, Description: "apply Unicode NFKC normalization before tokenizing", Default: false},
+			{Name: "stopwords", Type: eval.BoolParam, Description: "drop common English stopwords", Default: false},
+			{Name: "stemming", Type: eval.BoolParam, Description: "reduce words to their Porter stem", Default: false},
+		},
+		NewPairwise: func(params eval.Params) (eval.PairwiseMetric, error) {
+			pipeline := TextPipeline{Normalizers: []Normalizer{Lowercase, ReplacePunctuation}}
+			if params.Bool("nfkc") {
+				pipeline.Normalizers = append([]Normalizer{NFKC}, pipeline.Normalizers...)
+			}
+			if params.Bool("stopwords") {
+				pipeline.Filters = append(pipeline.Filters, RemoveStopwords(EnglishStopwords))
+			}
+			if params.Bool("stemming") {
+				pipeline.Filters = append(pipeline.Filters, Stem(PorterStem))
+			}
+			return WordOverlapWith(pipeline.Tokenize), nil
+		},
+	})
+	eval.Register(eval.MetricFactory{
+		Name:        "bleu",
+		Description: "Computes the sentence-level BLEU score between 0 and 100, like SacreBLEU",
+		Params: []eval.ParamSpec{
+			{Name: "max_order", Type: eval.IntParam, Description: "largest n-gram order", Default: 4},
+			{Name: "smoothing", Type: eval.StringParam, Description: "smoothing method: none, floor, add-k or exp", Default: string(SmoothExp)},
+			{Name: "smooth_value", Type: eval.FloatParam, Description: "value of the floor and add-k smoothing, 0 for their default", Default: 0.0},
+			{Name: "tokenizer", Type: eval.StringParam, Description: "tokenizer: 13a, intl or none", Default: string(Tokenize13a)},
+			{Name: "lowercase", Type: eval.BoolParam, Description: "lowercase texts before tokenizing them", Default: false},
+		},
+		NewPairwise: func(params eval.Params) (eval.PairwiseMetric, error) {
+			opts := BLEUOptions{
+				MaxOrder:    params.Int("max_order"),
+				Smoothing:   BLEUSmoothing(params.String("smoothing")),
+				SmoothValue: params.Float("smooth_value"),
+				Tokenizer:   BLEUTokenizer(params.String("tokenizer")),
+				Lowercase:   params.Bool("lowercase"),
+			}
+			if _, err := opts.withDefaults(); err != nil {
+				return eval.PairwiseMetric{}, err
+			}
+			return BLEU(opts), nil
+		},
+	})
+	for _, newMetric := range []func(ROUGEOptions) eval.PairwiseMetric{
+		func(opts ROUGEOptions) eval.PairwiseMetric { return ROUGEN(1, opts) },
+		func(opts ROUGEOptions) eval.PairwiseMetric { return ROUGEN(2, opts) },
+		ROUGEL,
+		ROUGELsum,
+	} {
+		eval.Register(rougeFactory(newMetric))
+	}
+	for _, newMetric := range []func(ChrFOptions) eval.PairwiseMetric{ChrF, ChrFPlusPlus} {
+		eval.Register(chrfFactory(newMetric))
+	}
+}
+
+// chrfFactory creates the factory of a chrF metric, named and described after it
+func chrfFactory(newMetric func(ChrFOptions) eval.PairwiseMetric) eval.MetricFactory {
+	metric := newMetric(ChrFOptions{})
+	return eval.MetricFactory{
+		Name:        metric.Name,
+		Description: metric.Description,
+		Params: []eval.ParamSpec{
+			{Name: "char_order", Type: eval.IntParam, Description: "largest character n-gram order", Default: 6},
+			{Name: "word_order", Type: eval.IntParam, Description: "largest word n-gram order, 0 for the metric's default", Default: 0},
+			{Name: "beta", Type: eval.FloatParam, Description: "weight of recall over precision", Default: 2.0},
+			{Name: "whitespace", Type: eval.BoolParam, Description: "keep whitespace in character n-grams", Default: false},
+		},
+		NewPairwise: func(params eval.Params) (eval.PairwiseMetric, error) {
+			opts := ChrFOptions{
+				CharOrder:  params.Int("char_order"),
+				WordOrder:  params.Int("word_order"),
+				Beta:       params.Float("beta"),
+				Whitespace: params.Bool("whitespace"),
+			}
+			if _, err := opts.withDefaults(); err != nil {
+				return eval.PairwiseMetric{}, err
+			}
+			return newMetric(opts), nil
+		},
+	}
+}
+
+// rougeFactory creates the factory of a ROUGE metric, named and described after it
+func rougeFactory(newMetric func(ROUGEOptions) eval.PairwiseMetric) eval.MetricFactory {
+	metric := newMetric(ROUGEOptions{})
+	return eval.MetricFactory{
+		Name:        metric.Name,
+		Description: metric.Description,
+		Params: []eval.ParamSpec{
+			{Name: "stemming", Type: eval.BoolParam, Description: "reduce words to their Porter stem", Default: false},
+		},
+		NewPairwise: func(params eval.Params) (eval.PairwiseMetric, error) {
+			return newMetric(ROUGEOptions{Stemming: params.Bool("stemming")}), nil
+		},
+	}
+}
+
+// pairwiseFactory creates the factory of a parameterless pairwise metric, named and described after it
+func pairwiseFactory(newMetric func() eval.PairwiseMetric) eval.MetricFactory {
+	metric := newMetric()
+	return eval.MetricFactory{
+		Name:        metric.Name,
+		Description: metric.Description,
+		NewPairwise: func(eval.Params) (eval.PairwiseMetric, error) {
+			return newMetric(), nil
+		},
+	}
+}
+
+// pointwiseFactory creates the factory of a parameterless pointwise metric, named and described after it
+func pointwiseFactory(newMetric func() eval.PointwiseMetric) eval.MetricFactory {
+	metric := newMetric()
+	return eval.MetricFactory{
+		Name:        metric.Name,
+		Description: metric.Description,
+		NewPointwise: func(eval.Params) (eval.PointwiseMetric, error) {
+			return newMetric(), nil
+		},
+	}
+}
@@ -0,0 +1,251 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// rougeOutputs are the outputs of the ROUGE metrics, the F-measure being their own score
+var rougeOutputs = []string{"", "precision", "recall"}
+
+// ROUGEOptions configures the ROUGE metrics
+type ROUGEOptions struct {
+	// Stemming reduces words longer than three letters to their Porter stem with NLTK's extensions of the
+	// algorithm, as rouge-score does
+	Stemming bool
+	// Tokenizer splits texts into words, nil means DefaultTokenizer
+	Tokenizer Tokenizer
+}
+
+// ROUGEN returns a pairwise metric that computes ROUGE-N, the overlap of the n-grams of reference and prediction,
+// named rouge_n, e.g. rouge_1. Its score is the F-measure, precision and recall are reported as rouge_n.precision
+// and rouge_n.recall. Words are lowercased and stripped of punctuation like WordOverlap, unless
+// opts.Tokenizer is set.
+func ROUGEN(n int, opts ROUGEOptions) eval.PairwiseMetric {
+	return eval.NewMultiOutputPairwiseMetric(
+		fmt.Sprintf("rouge_%d", n),
+		fmt.Sprintf("Computes the F-measure, precision and recall of the overlapping %d-grams", n),
+		rougeOutputs,
+		func(ctx context.Context, references, predictions []string) (map[string][]float64, error) {
+			if n < 1 {
+				return nil, fmt.Errorf("invalid ROUGE n-gram order %d", n)
+			}
+			return rougeScores(ctx, references, predictions, func(reference, prediction string) (float64, float64, float64) {
+				referenceCounts := countRougeNgrams(rougeTokens(reference, opts), n)
+				predictionCounts := countRougeNgrams(rougeTokens(prediction, opts), n)
+
+				overlap, referenceTotal, predictionTotal := 0, 0, 0
+				for ngram, count := range referenceCounts {
+					overlap += min(count, predictionCounts[ngram])
+					referenceTotal += count
+				}
+				for _, count := range predictionCounts {
+					predictionTotal += count
+				}
+				return rougeFMeasure(overlap, predictionTotal, referenceTotal)
+			})
+		},
+	)
+}
+
+// ROUGEL returns a pairwise metric that computes ROUGE-L from the longest common subsequence of the words of
+// reference and prediction. Its score is the F-measure, precision and recall are reported as rouge_l.precision
+// and rouge_l.recall.
+func ROUGEL(opts ROUGEOptions) eval.PairwiseMetric {
+	return eval.NewMultiOutputPairwiseMetric(
+		"rouge_l",
+		"Computes the F-measure, precision and recall of the longest common subsequence of words",
+		rougeOutputs,
+		func(ctx context.Context, references, predictions []string) (map[string][]float64, error) {
+			return rougeScores(ctx, references, predictions, func(reference, prediction string) (float64, float64, float64) {
+				referenceTokens, predictionTokens := rougeTokens(reference, opts), rougeTokens(prediction, opts)
+				return rougeFMeasure(lcsLength(referenceTokens, predictionTokens), len(predictionTokens), len(referenceTokens))
+			})
+		},
+	)
+}
+
+// ROUGELsum returns a pairwise metric that computes summary-level ROUGE-L, which treats each line of reference
+// and prediction as a sentence and matches every reference sentence with the union of its longest common
+// subsequences with the prediction sentences. Its score is the F-measure, precision and recall are reported
+// as rouge_lsum.precision and rouge_lsum.recall.
+func ROUGELsum(opts ROUGEOptions) eval.PairwiseMetric {
+	return eval.NewMultiOutputPairwiseMetric(
+		"rouge_lsum",
+		"Computes the summary-level F-measure, precision and recall of the longest common subsequences of sentences",
+		rougeOutputs,
+		func(ctx context.Context, references, predictions []string) (map[string][]float64, error) {
+			return rougeScores(ctx, references, predictions, func(reference, prediction string) (float64, float64, float64) {
+				return summaryLCS(rougeSentences(reference, opts), rougeSentences(prediction, opts))
+			})
+		},
+	)
+}
+
+// rougeScores scores every reference-prediction pair with score, which returns the F-measure, precision and recall
+func rougeScores(ctx context.Context, references, predictions []string, score func(reference, prediction string) (float64, float64, float64)) (map[string][]float64, error) {
+	fMeasures := make([]float64, len(predictions))
+	precisions := make([]float64, len(predictions))
+	recalls := make([]float64, len(predictions))
+	for i := range predictions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fMeasures[i], precisions[i], recalls[i] = score(references[i], predictions[i])
+	}
+	return map[string][]float64{"": fMeasures, "precision": precisions, "recall": recalls}, nil
+}
+
+// rougeFMeasure returns the F-measure, precision and recall of matches among the given prediction and reference counts
+func rougeFMeasure(matches, predictionTotal, referenceTotal int) (float64, float64, float64) {
+	var precision, recall float64
+	if predictionTotal > 0 {
+		precision = float64(matches) / float64(predictionTotal)
+	}
+	if referenceTotal > 0 {
+		recall = float64(matches) / float64(referenceTotal)
+	}
+	if precision+recall == 0 {
+		return 0, precision, recall
+	}
+	return 2 * precision * recall / (precision + recall), precision, recall
+}
+
+// rougeTokens splits text into words with the tokenizer of opts, stemming those longer than three letters when enabled
+func rougeTokens(text string, opts ROUGEOptions) []string {
+	tokenize := opts.Tokenizer
+	if tokenize == nil {
+		tokenize = DefaultTokenizer
+	}
+	tokens := tokenize(text)
+	if opts.Stemming {
+		for i, token := range tokens {
+			if len(token) > 3 {
+				tokens[i] = PorterStem(token)
+			}
+		}
+	}
+	return tokens
+}
+
+// rougeSentences splits text into its non-empty lines and each line into words
+func rougeSentences(text string, opts ROUGEOptions) [][]string {
+	var sentences [][]string
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			sentences = append(sentences, rougeTokens(line, opts))
+		}
+	}
+	return sentences
+}
+
+// countRougeNgrams counts the n-grams of tokens
+func countRougeNgrams(tokens []string, n int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i+n <= len(tokens); i++ {
+		counts[strings.Join(tokens[i:i+n], " ")]++
+	}
+	return counts
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b in O(len(b)) memory
+func lcsLength(a, b []string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				current[j+1] = previous[j] + 1
+			} else {
+				current[j+1] = max(current[j], previous[j+1])
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// lcsIndices returns the indices in a of one longest common subsequence of a and b,
+// backtracking like rouge-score so that scores match it
+func lcsIndices(a, b []string) []int {
+	// lengths[i][j] is the length of the longest common subsequence of a[:i] and b[:j]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				lengths[i][j] = lengths[i-1][j-1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i-1][j], lengths[i][j-1])
+			}
+		}
+	}
+
+	var indices []int
+	for i, j := len(a), len(b); i > 0 && j > 0; {
+		switch {
+		case a[i-1] == b[j-1]:
+			indices = append(indices, i-1)
+			i--
+			j--
+		case lengths[i][j-1] > lengths[i-1][j]:
+			j--
+		default:
+			i--
+		}
+	}
+	return indices
+}
+
+// summaryLCS returns the summary-level LCS F-measure, precision and recall of candidate sentences against
+// reference sentences. Each word of either side is matched at most as many times as it occurs on that side.
+func summaryLCS(references, candidates [][]string) (float64, float64, float64) {
+	referenceCounts, candidateCounts := make(map[string]int), make(map[string]int)
+	referenceTotal, candidateTotal := 0, 0
+	for _, sentence := range references {
+		referenceTotal += len(sentence)
+		for _, token := range sentence {
+			referenceCounts[token]++
+		}
+	}
+	for _, sentence := range candidates {
+		candidateTotal += len(sentence)
+		for _, token := range sentence {
+			candidateCounts[token]++
+		}
+	}
+	if referenceTotal == 0 || candidateTotal == 0 {
+		return 0, 0, 0
+	}
+
+	hits := 0
+	for _, reference := range references {
+		union := make(map[int]bool)
+		for _, candidate := range candidates {
+			for _, i := range lcsIndices(reference, candidate) {
+				union[i] = true
+			}
+		}
+		indices := make([]int, 0, len(union))
+		for i := range union {
+			indices = append(indices, i)
+		}
+		sort.Ints(indices)
+
+		for _, i := range indices {
+			token := reference[i]
+			if referenceCounts[token] > 0 && candidateCounts[token] > 0 {
+				hits++
+				referenceCounts[token]--
+				candidateCounts[token]--
+			}
+		}
+	}
+	return rougeFMeasure(hits, candidateTotal, referenceTotal)
+}
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// rougeCase holds the F-measure, precision and recall rouge-score reports for a target and a prediction
+type rougeCase struct {
+	name      string
+	reference string
+	candidate string
+	fMeasure  float64
+	precision float64
+	recall    float64
+}
+
+func checkROUGE(t *testing.T, metric eval.PairwiseMetric, tests []rougeCase) {
+	t.Helper()
+	for _, tt := range tests {
+		outputs, err := metric.ComputeOutputs(context.Background(), []string{tt.reference}, []string{tt.candidate})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for output, want := range map[string]float64{"": tt.fMeasure, "precision": tt.precision, "recall": tt.recall} {
+			if got := outputs[output][0]; math.Abs(got-want) > 1e-9 {
+				t.Errorf("%s %s: output %q = %.10g, want %.10g", metric.Name, tt.name, output, got, want)
+			}
+		}
+	}
+}
+
+const (
+	rougeTarget     = "The quick brown fox jumps over the lazy dog"
+	rougePrediction = "The quick brown dog jumps on the log."
+)
+
+func TestROUGE1(t *testing.T) {
+	tests := []rougeCase{
+		// From the rouge-score README
+		{"readme", rougeTarget, rougePrediction, 0.7058823529411765, 0.75, 0.6666666666666666},
+		{"identical", "a b c", "a  B, c!", 1, 1, 1},
+		{"disjoint", "a b c", "d e", 0, 0, 0},
+		{"empty prediction", "a b c", "", 0, 0, 0},
+		{"repeated words", "the the cat", "the the the", 2.0 / 3, 2.0 / 3, 2.0 / 3},
+		{"without stemming", "The cats are running quickly", "A cat runs quick", 0, 0, 0},
+	}
+	checkROUGE(t, ROUGEN(1, ROUGEOptions{}), tests)
+
+	checkROUGE(t, ROUGEN(1, ROUGEOptions{Stemming: true}), []rougeCase{
+		{"readme", rougeTarget, rougePrediction, 0.7058823529411765, 0.75, 0.6666666666666666},
+		// cats and cat match, as do running and runs, but words of three letters or less are not stemmed
+		{"with stemming", "The cats are running quickly", "A cat runs quick", 4.0 / 9, 0.5, 0.4},
+	})
+}
+
+func TestROUGE2(t *testing.T) {
+	tests := []rougeCase{
+		{"readme", rougeTarget, rougePrediction, 4.0 / 15, 2.0 / 7, 0.25},
+		{"single word", "a", "a", 0, 0, 0},
+		{"identical", "a b c", "a b c", 1, 1, 1},
+	}
+	checkROUGE(t, ROUGEN(2, ROUGEOptions{}), tests)
+	checkROUGE(t, ROUGEN(2, ROUGEOptions{Stemming: true}), []rougeCase{
+		{"readme", rougeTarget, rougePrediction, 4.0 / 15, 2.0 / 7, 0.25},
+		{"with stemming", "the cats are running", "his cats were running fast", 0, 0, 0},
+		{"stemmed bigrams", "black cats running", "black cat runs", 1, 1, 1},
+	})
+}
+
+func TestROUGEL(t *testing.T) {
+	tests := []rougeCase{
+		// From the rouge-score README
+		{"readme", rougeTarget, rougePrediction, 0.5882352941176471, 0.625, 0.5555555555555556},
+		{"reordered", "a b c d", "d c b a", 0.25, 0.25, 0.25},
+		// Newlines are ignored, unlike with rouge_lsum
+		{"sentences", "a b\nc d", "c d\na b", 0.5, 0.5, 0.5},
+	}
+	checkROUGE(t, ROUGEL(ROUGEOptions{}), tests)
+	checkROUGE(t, ROUGEL(ROUGEOptions{Stemming: true}), []rougeCase{
+		{"readme", rougeTarget, rougePrediction, 0.5882352941176471, 0.625, 0.5555555555555556},
+		{"with stemming", "The cats are running quickly", "A cat runs quick", 4.0 / 9, 0.5, 0.4},
+	})
+}
+
+func TestROUGELsum(t *testing.T) {
+	tests := []rougeCase{
+		// From the rouge-score tests, the example of the ROUGE paper
+		{"union of subsequences", "w1 w2 w3 w4 w5", "w1 w2 w6 w7 w8\nw1 w3 w8 w9 w5", 0.5333333333333333, 0.4, 0.8},
+		{"reordered sentences", "a b\nc d", "c d\na b", 1, 1, 1},
+		{"blank lines", "a b\n\nc d", "a b c d\n", 1, 1, 1},
+		{"empty prediction", "a b", "", 0, 0, 0},
+		// The union of the subsequences holds each word of a reference sentence once
+		{"repeated words", "w1 w1 w1", "w1\nw1", 0.4, 0.5, 1.0 / 3},
+		// A word matches at most as many times as it occurs in the prediction
+		{"repeated sentences", "w1\nw1", "w1", 2.0 / 3, 1, 0.5},
+	}
+	checkROUGE(t, ROUGELsum(ROUGEOptions{}), tests)
+	checkROUGE(t, ROUGELsum(ROUGEOptions{Stemming: true}), []rougeCase{
+		{"with stemming", "the cats were running\nfast", "cat runs\nfast", 0.75, 1, 0.6},
+	})
+}
+
+func TestROUGEMultiReference(t *testing.T) {
+	evaluation := eval.NewPairwiseEvaluation("summaries", "", []eval.PairwiseMetric{ROUGEN(1, ROUGEOptions{})})
+	results, err := evaluation.Run(context.Background(), []eval.Instance{{
+		Reference:  "a b c d e f",
+		References: []string{"a"},
+		Prediction: "a b",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every output comes from the second reference, the one with the best F-measure
+	want := map[string]float64{"rouge_1": 2.0 / 3, "rouge_1.precision": 0.5, "rouge_1.recall": 1}
+	for key, score := range want {
+		if got := results[0].MetricResults[key]; math.Abs(got-score) > 1e-9 {
+			t.Errorf("%s = %g, want %g", key, got, score)
+		}
+	}
+}
+
+func TestROUGEInvalidOrder(t *testing.T) {
+	metric := ROUGEN(0, ROUGEOptions{})
+	if _, err := metric.ComputeOutputs(context.Background(), []string{"a"}, []string{"a"}); err == nil {
+		t.Error("ROUGE-0 succeeded, want an error")
+	}
+}
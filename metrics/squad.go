@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// squadPipeline normalizes answers like the SQuAD evaluation script: it lowercases them, deletes ASCII
+// punctuation, so "don't" is the single word "dont", splits them on whitespace and drops articles.
+// Like the script, it keeps non-ASCII punctuation such as "«" or "—".
+var squadPipeline = TextPipeline{
+	Normalizers: []Normalizer{Lowercase, deleteASCIIPunctuation},
+	Filters:     []TokenFilter{RemoveStopwords([]string{"a", "an", "the"})},
+}
+
+// SQuAD returns a pairwise metric that computes the exact match and token F1 of SQuAD question answering
+// evaluations, reported as squad.exact_match and squad.f1. Answers are normalized like the official
+// evaluation script: lowercased, stripped of ASCII punctuation and articles, with whitespace collapsed.
+// Predictions with several references are given the best score of each against any of them, and its
+// details hold the normalized answers.
+func SQuAD() eval.PairwiseMetric {
+	return eval.NewMultiOutputPairwiseMetric(
+		"squad",
+		"Computes the normalized exact match and token F1 of question answering",
+		[]string{"exact_match", "f1"},
+		func(ctx context.Context, references, predictions []string) (map[string][]float64, error) {
+			exactMatches := make([]float64, len(predictions))
+			f1s := make([]float64, len(predictions))
+			for i := range predictions {
+				referenceTokens := squadPipeline.Tokenize(references[i])
+				predictionTokens := squadPipeline.Tokenize(predictions[i])
+				if strings.Join(referenceTokens, " ") == strings.Join(predictionTokens, " ") {
+					exactMatches[i] = 1.0
+				}
+				f1s[i] = tokenF1(referenceTokens, predictionTokens)
+				eval.AttachDetails(ctx, i, eval.Details{
+					"normalized_reference":  strings.Join(referenceTokens, " "),
+					"normalized_prediction": strings.Join(predictionTokens, " "),
+				})
+			}
+			return map[string][]float64{"exact_match": exactMatches, "f1": f1s}, nil
+		},
+	)
+}
+
+// NormalizeAnswer normalizes an answer like the SQuAD evaluation script, e.g. "The Eiffel Tower!" to "eiffel tower"
+func NormalizeAnswer(text string) string {
+	return strings.Join(squadPipeline.Tokenize(text), " ")
+}
+
+// deleteASCIIPunctuation deletes the ASCII punctuation characters of text
+func deleteASCIIPunctuation(text string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(asciiPunctuation, r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// tokenF1 returns the F1 of the tokens shared by reference and prediction, counting repeated tokens.
+// It is 1 when both are empty and 0 when only one of them is.
+func tokenF1(reference, prediction []string) float64 {
+	if len(reference) == 0 || len(prediction) == 0 {
+		if len(reference) == len(prediction) {
+			return 1.0
+		}
+		return 0.0
+	}
+
+	counts := make(map[string]int, len(reference))
+	for _, token := range reference {
+		counts[token]++
+	}
+	shared := 0
+	for _, token := range prediction {
+		if counts[token] > 0 {
+			counts[token]--
+			shared++
+		}
+	}
+	if shared == 0 {
+		return 0.0
+	}
+
+	precision := float64(shared) / float64(len(prediction))
+	recall := float64(shared) / float64(len(reference))
+	return 2 * precision * recall / (precision + recall)
+}
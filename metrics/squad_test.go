@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	eval "github.com/snpu/eval-go"
+)
+
+func TestNormalizeAnswer(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"The Eiffel Tower!", "eiffel tower"},
+		{"  An   apple, a day ", "apple day"},
+		{"don't", "dont"},
+		{"Theater", "theater"},
+		{"rock—roll «live»", "rock—roll «live»"},
+		{"the", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeAnswer(tt.text); got != tt.want {
+			t.Errorf("NormalizeAnswer(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSQuAD(t *testing.T) {
+	metric := SQuAD()
+	references := []string{"The cat sat on the mat.", "Denver Broncos", "", "1,000"}
+	predictions := []string{"cat on mat", "the denver broncos!", "", "one thousand"}
+
+	outputs, err := metric.ComputeOutputs(context.Background(), references, predictions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantExactMatch := []float64{0, 1, 1, 0}
+	wantF1 := []float64{6.0 / 7, 1, 1, 0}
+	for i := range predictions {
+		if got := outputs["exact_match"][i]; got != wantExactMatch[i] {
+			t.Errorf("exact_match of %q = %g, want %g", predictions[i], got, wantExactMatch[i])
+		}
+		if got := outputs["f1"][i]; math.Abs(got-wantF1[i]) > 1e-12 {
+			t.Errorf("f1 of %q = %g, want %g", predictions[i], got, wantF1[i])
+		}
+	}
+}
+
+func TestSQuADMultiReference(t *testing.T) {
+	metric := SQuAD()
+	evaluation := eval.NewPairwiseEvaluation("qa", "", []eval.PairwiseMetric{metric})
+	results, err := evaluation.Run(context.Background(), []eval.Instance{{
+		Reference:  "Denver Broncos",
+		References: []string{"the Broncos of Denver"},
+		Prediction: "Broncos",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Like the official script, each score is the best one against any reference
+	if got := results[0].MetricResults["squad.exact_match"]; got != 0 {
+		t.Errorf("exact_match = %g, want 0", got)
+	}
+	if got, want := results[0].MetricResults["squad.f1"], 2.0/3; math.Abs(got-want) > 1e-12 {
+		t.Errorf("f1 = %g, want %g", got, want)
+	}
+}
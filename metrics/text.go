@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalizer transforms a text before it is split into tokens, e.g. Lowercase or NFKC
+type Normalizer func(text string) string
+
+// Tokenizer splits a text into tokens, e.g. DefaultTokenizer or WhitespaceTokenizer
+type Tokenizer func(text string) []string
+
+// TokenFilter transforms the tokens of a text, e.g. to remove stopwords or reduce words to their stem
+type TokenFilter func(tokens []string) []string
+
+// TextPipeline tokenizes texts by applying its normalizers in order, splitting the result with its
+// tokenizer and applying its filters in order to the tokens
+type TextPipeline struct {
+	Normalizers []Normalizer
+	// Tokenizer splits the normalized text, nil means WhitespaceTokenizer
+	Tokenizer Tokenizer
+	Filters   []TokenFilter
+}
+
+// Tokenize runs the pipeline on text. Its method value is a Tokenizer, e.g. WordOverlapWith(pipeline.Tokenize).
+func (p TextPipeline) Tokenize(text string) []string {
+	for _, normalize := range p.Normalizers {
+		text = normalize(text)
+	}
+
+	tokenize := p.Tokenizer
+	if tokenize == nil {
+		tokenize = WhitespaceTokenizer
+	}
+	tokens := tokenize(text)
+
+	for _, filter := range p.Filters {
+		tokens = filter(tokens)
+	}
+	return tokens
+}
+
+// DefaultTokenizer splits a text into lowercase words, turning punctuation into word separators.
+// It is the tokenizer of WordOverlap and the ROUGE metrics and runs in linear time.
+func DefaultTokenizer(text string) []string {
+	return WhitespaceTokenizer(ReplacePunctuation(Lowercase(text)))
+}
+
+// Lowercase lowercases text
+func Lowercase(text string) string {
+	return strings.ToLower(text)
+}
+
+// NFKC applies Unicode compatibility normalization to text, e.g. turning "ﬁ" into "fi" and full-width digits into ASCII ones
+func NFKC(text string) string {
+	return norm.NFKC.String(text)
+}
+
+// ReplacePunctuation replaces every Unicode punctuation character of text with a space
+func ReplacePunctuation(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) {
+			return ' '
+		}
+		return r
+	}, text)
+}
+
+// WhitespaceTokenizer splits text on Unicode whitespace
+func WhitespaceTokenizer(text string) []string {
+	return strings.Fields(text)
+}
+
+// WordBoundaryTokenizer splits text into its runs of letters, marks and digits, dropping everything else
+func WordBoundaryTokenizer(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsNumber(r)
+	})
+}
+
+// RegexpTokenizer returns a tokenizer splitting text into the matches of pattern, e.g. `\w+|[^\w\s]`
+func RegexpTokenizer(pattern *regexp.Regexp) Tokenizer {
+	return func(text string) []string {
+		return pattern.FindAllString(text, -1)
+	}
+}
+
+// RemoveStopwords returns a filter dropping the given words, e.g. EnglishStopwords, from tokens
+func RemoveStopwords(stopwords []string) TokenFilter {
+	set := make(map[string]bool, len(stopwords))
+	for _, word := range stopwords {
+		set[word] = true
+	}
+	return func(tokens []string) []string {
+		kept := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			if !set[token] {
+				kept = append(kept, token)
+			}
+		}
+		return kept
+	}
+}
+
+// Stem returns a filter reducing tokens to their stem with stem, e.g. Stem(PorterStem)
+func Stem(stem func(word string) string) TokenFilter {
+	return func(tokens []string) []string {
+		stemmed := make([]string, len(tokens))
+		for i, token := range tokens {
+			stemmed[i] = stem(token)
+		}
+		return stemmed
+	}
+}
+
+// EnglishStopwords are common lowercase English function words carrying little meaning on their own
+var EnglishStopwords = []string{
+	"a", "about", "above", "after", "again", "against", "all", "am", "an", "and", "any", "are", "as", "at",
+	"be", "because", "been", "before", "being", "below", "between", "both", "but", "by",
+	"can", "could", "did", "do", "does", "doing", "down", "during", "each", "few", "for", "from", "further",
+	"had", "has", "have", "having", "he", "her", "here", "hers", "herself", "him", "himself", "his", "how",
+	"i", "if", "in", "into", "is", "it", "its", "itself", "just", "me", "more", "most", "my", "myself",
+	"no", "nor", "not", "now", "of", "off", "on", "once", "only", "or", "other", "our", "ours", "ourselves",
+	"out", "over", "own", "same", "she", "should", "so", "some", "such",
+	"than", "that", "the", "their", "theirs", "them", "themselves", "then", "there", "these", "they",
+	"this", "those", "through", "to", "too", "under", "until", "up", "very",
+	"was", "we", "were", "what", "when", "where", "which", "while", "who", "whom", "why", "will", "with", "would",
+	"you", "your", "yours", "yourself", "yourselves",
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTokenizers(t *testing.T) {
+	text := "Don't  stop—ﬁnd 42 cafés!"
+	tests := []struct {
+		name     string
+		tokenize Tokenizer
+		want     []string
+	}{
+		{"default", DefaultTokenizer, []string{"don", "t", "stop", "ﬁnd", "42", "cafés"}},
+		{"whitespace", WhitespaceTokenizer, []string{"Don't", "stop—ﬁnd", "42", "cafés!"}},
+		{"word boundary", WordBoundaryTokenizer, []string{"Don", "t", "stop", "ﬁnd", "42", "cafés"}},
+		{"regexp", RegexpTokenizer(regexp.MustCompile(`\d+|!`)), []string{"42", "!"}},
+		{"NFKC pipeline", TextPipeline{Normalizers: []Normalizer{NFKC, Lowercase}}.Tokenize, []string{"don't", "stop—find", "42", "cafés!"}},
+	}
+	for _, tt := range tests {
+		if got := tt.tokenize(text); fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("%s: tokens = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTextPipeline(t *testing.T) {
+	pipeline := TextPipeline{
+		Normalizers: []Normalizer{Lowercase, ReplacePunctuation},
+		Filters:     []TokenFilter{RemoveStopwords(EnglishStopwords), Stem(PorterStem)},
+	}
+	got := pipeline.Tokenize("The cats were RUNNING, and jumping!")
+	if want := []string{"cat", "run", "jump"}; fmt.Sprintf("%q", got) != fmt.Sprintf("%q", want) {
+		t.Errorf("tokens = %q, want %q", got, want)
+	}
+	if got := (TextPipeline{}).Tokenize(" a  b "); fmt.Sprintf("%q", got) != `["a" "b"]` {
+		t.Errorf("empty pipeline tokens = %q, want whitespace tokens", got)
+	}
+}
+
+func TestWordOverlap(t *testing.T) {
+	pipeline := TextPipeline{
+		Normalizers: []Normalizer{Lowercase, ReplacePunctuation},
+		Filters:     []TokenFilter{RemoveStopwords(EnglishStopwords), Stem(PorterStem)},
+	}
+	tests := []struct {
+		name                  string
+		tokenize              Tokenizer
+		reference, prediction string
+		want                  float64
+	}{
+		{"default", DefaultTokenizer, "The cat sat.", "the CAT ran!", 0.5},
+		{"empty", DefaultTokenizer, "", "...", 1},
+		{"one empty", DefaultTokenizer, "cat", "", 0},
+		{"pipeline", pipeline.Tokenize, "The cats are running", "a cat runs", 1},
+	}
+	for _, tt := range tests {
+		metric := WordOverlapWith(tt.tokenize)
+		scores, err := metric.Compute(context.Background(), []string{tt.reference}, []string{tt.prediction})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if scores[0] != tt.want {
+			t.Errorf("%s: word_overlap(%q, %q) = %g, want %g", tt.name, tt.reference, tt.prediction, scores[0], tt.want)
+		}
+	}
+}
+
+func TestDefaultTokenizerLongText(t *testing.T) {
+	// Punctuation-heavy text used to take quadratic time
+	text := strings.Repeat("a,b.c! ", 200_000)
+	if tokens := DefaultTokenizer(text); len(tokens) != 600_000 {
+		t.Errorf("got %d tokens, want 600000", len(tokens))
+	}
+}
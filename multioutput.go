@@ -0,0 +1,192 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+)
+
+// NewMultiOutputPairwiseMetric creates a pairwise metric computing several named scores per instance in a
+// single pass, e.g. precision, recall and f1. The score of each output is recorded under "name.output",
+// an empty output name records it under the metric's name, which gives the metric a score of its own.
+// The metric fails to compute when two outputs share a name.
+func NewMultiOutputPairwiseMetric(name, description string, outputs []string, compute MultiOutputPairwiseMetricFunc) PairwiseMetric {
+	outputs = append(make([]string, 0, len(outputs)), outputs...)
+	outputsErr := checkOutputs(outputs)
+	return PairwiseMetric{
+		Name:        name,
+		Description: description,
+		outputs:     outputs,
+		computeOutputs: func(ctx context.Context, references, predictions []string) ([][]float64, error) {
+			if outputsErr != nil {
+				return nil, outputsErr
+			}
+			scores, err := compute(ctx, references, predictions)
+			return outputColumns(outputs, scores, err)
+		},
+	}
+}
+
+// NewMultiOutputPointwiseMetric creates a pointwise metric computing several named scores per instance in a
+// single pass, e.g. the count and size of quotes. The score of each output is recorded under "name.output",
+// an empty output name records it under the metric's name, which gives the metric a score of its own.
+// The metric fails to compute when two outputs share a name.
+func NewMultiOutputPointwiseMetric(name, description string, outputs []string, compute MultiOutputPointwiseMetricFunc) PointwiseMetric {
+	outputs = append(make([]string, 0, len(outputs)), outputs...)
+	outputsErr := checkOutputs(outputs)
+	return PointwiseMetric{
+		Name:        name,
+		Description: description,
+		outputs:     outputs,
+		computeOutputs: func(ctx context.Context, predictions []string) ([][]float64, error) {
+			if outputsErr != nil {
+				return nil, outputsErr
+			}
+			scores, err := compute(ctx, predictions)
+			return outputColumns(outputs, scores, err)
+		},
+	}
+}
+
+// Outputs returns the names of the scores the metric computes per instance, "" standing for its own score
+func (m *PairwiseMetric) Outputs() []string {
+	if m.outputs == nil {
+		return []string{""}
+	}
+	return append([]string(nil), m.outputs...)
+}
+
+// Outputs returns the names of the scores the metric computes per instance, "" standing for its own score
+func (m *PointwiseMetric) Outputs() []string {
+	if m.outputs == nil {
+		return []string{""}
+	}
+	return append([]string(nil), m.outputs...)
+}
+
+// WithOutputAggregator returns a copy of the metric that summarizes the named output with the given
+// aggregator, e.g. a total next to an average. Outputs without an aggregator of their own use Aggregator.
+func (m PairwiseMetric) WithOutputAggregator(output string, aggregator Aggregator) PairwiseMetric {
+	m.outputAggregators = withOutputAggregator(m.outputAggregators, output, aggregator)
+	return m
+}
+
+// WithOutputAggregator returns a copy of the metric that summarizes the named output with the given
+// aggregator, e.g. a total next to an average. Outputs without an aggregator of their own use Aggregator.
+func (m PointwiseMetric) WithOutputAggregator(output string, aggregator Aggregator) PointwiseMetric {
+	m.outputAggregators = withOutputAggregator(m.outputAggregators, output, aggregator)
+	return m
+}
+
+// OutputAggregator returns the aggregator summarizing the named output, the zero value meaning MeanAggregator
+func (m *PairwiseMetric) OutputAggregator(output string) Aggregator {
+	return outputAggregator(m.Aggregator, m.outputAggregators, output)
+}
+
+// OutputAggregator returns the aggregator summarizing the named output, the zero value meaning MeanAggregator
+func (m *PointwiseMetric) OutputAggregator(output string) Aggregator {
+	return outputAggregator(m.Aggregator, m.outputAggregators, output)
+}
+
+// withOutputAggregator returns a copy of aggregators setting the aggregator of output, leaving the original
+// untouched since it is shared with the metric being copied
+func withOutputAggregator(aggregators map[string]Aggregator, output string, aggregator Aggregator) map[string]Aggregator {
+	copied := make(map[string]Aggregator, len(aggregators)+1)
+	for name, existing := range aggregators {
+		copied[name] = existing
+	}
+	copied[output] = aggregator
+	return copied
+}
+
+func outputAggregator(aggregator Aggregator, aggregators map[string]Aggregator, output string) Aggregator {
+	if outputAggregator, ok := aggregators[output]; ok {
+		return outputAggregator
+	}
+	return aggregator
+}
+
+// checkOutputs returns an error for an output name declared twice, whose scores would overwrite each other
+func checkOutputs(outputs []string) error {
+	seen := make(map[string]bool, len(outputs))
+	for _, output := range outputs {
+		if seen[output] {
+			return fmt.Errorf("output %q is declared twice", output)
+		}
+		seen[output] = true
+	}
+	return nil
+}
+
+// ComputeOutputs executes the pairwise metric on the given references and predictions,
+// returning every score it computes keyed by output name
+func (m *PairwiseMetric) ComputeOutputs(ctx context.Context, references, predictions []string) (map[string][]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(references) == 0 {
+		return nil, fmt.Errorf("no references provided")
+	}
+
+	if len(references) != len(predictions) {
+		return nil, fmt.Errorf("number of references (%d) does not match number of predictions (%d)",
+			len(references), len(predictions))
+	}
+
+	columns, err := m.computeColumns(ctx, references, nil, predictions)
+	return outputScores(m.Outputs(), columns, err)
+}
+
+// ComputeOutputs executes the pointwise metric on the given predictions,
+// returning every score it computes keyed by output name
+func (m *PointwiseMetric) ComputeOutputs(ctx context.Context, predictions []string) (map[string][]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(predictions) == 0 {
+		return nil, fmt.Errorf("no predictions provided")
+	}
+
+	columns, err := m.computeColumns(ctx, predictions)
+	return outputScores(m.Outputs(), columns, err)
+}
+
+// outputColumns orders the scores returned by a multi-output metric function as one column per output
+func outputColumns(outputs []string, scores map[string][]float64, err error) ([][]float64, error) {
+	if scores == nil {
+		if err == nil {
+			err = fmt.Errorf("returned no scores for %d outputs", len(outputs))
+		}
+		return nil, err
+	}
+
+	columns := make([][]float64, len(outputs))
+	for i, output := range outputs {
+		column, ok := scores[output]
+		if !ok {
+			return nil, fmt.Errorf("returned no scores for output %q", output)
+		}
+		columns[i] = column
+	}
+	if len(scores) > len(outputs) {
+		return nil, fmt.Errorf("returned scores for %d outputs, %d declared", len(scores), len(outputs))
+	}
+	return columns, err
+}
+
+// outputScores keys the columns computed by computeColumns by output name
+func outputScores(outputs []string, columns [][]float64, err error) (map[string][]float64, error) {
+	if columns == nil {
+		return nil, err
+	}
+	if len(columns) != len(outputs) {
+		return nil, fmt.Errorf("returned %d score columns for %d outputs", len(columns), len(outputs))
+	}
+
+	scores := make(map[string][]float64, len(outputs))
+	for i, output := range outputs {
+		scores[output] = columns[i]
+	}
+	return scores, err
+}
@@ -0,0 +1,141 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// lengths scores each prediction with its length in bytes and words, and optionally its own score
+func lengths(outputs []string) PointwiseMetric {
+	return NewMultiOutputPointwiseMetric("lengths", "", outputs, func(ctx context.Context, predictions []string) (map[string][]float64, error) {
+		scores := make(map[string][]float64)
+		for _, prediction := range predictions {
+			scores["bytes"] = append(scores["bytes"], float64(len(prediction)))
+			scores["words"] = append(scores["words"], float64(len(strings.Fields(prediction))))
+			if len(outputs) == 3 {
+				scores[""] = append(scores[""], 1)
+			}
+		}
+		return scores, nil
+	})
+}
+
+func TestMultiOutputResultKeys(t *testing.T) {
+	metric := lengths([]string{"bytes", "words"})
+	if got := fmt.Sprint(metric.ResultKeys(), metric.Outputs()); got != "[lengths.bytes lengths.words] [bytes words]" {
+		t.Errorf("result keys and outputs = %s", got)
+	}
+	own := lengths([]string{"", "bytes", "words"})
+	if got := fmt.Sprint(own.ResultKeys()); got != "[lengths lengths.bytes lengths.words]" {
+		t.Errorf("result keys = %s", got)
+	}
+	single := NewPointwiseMetric("single", "", nil)
+	if got := fmt.Sprintf("%q %q", single.ResultKeys(), single.Outputs()); got != `["single"] [""]` {
+		t.Errorf("result keys and outputs of a single score metric = %s", got)
+	}
+}
+
+func TestRunMultiOutput(t *testing.T) {
+	evaluation := NewPointwiseEvaluation("lengths", "", []PointwiseMetric{lengths([]string{"bytes", "words"})})
+	evaluation.ShardSize = 1
+	results, err := evaluation.Run(context.Background(), []string{"a bc", "def"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]float64{
+		{"lengths.bytes": 4, "lengths.words": 2},
+		{"lengths.bytes": 3, "lengths.words": 1},
+	}
+	for i, result := range results {
+		if fmt.Sprint(result.MetricResults) != fmt.Sprint(want[i]) {
+			t.Errorf("result %d = %v, want %v", i, result.MetricResults, want[i])
+		}
+	}
+
+	summary := evaluation.Summarize(results)
+	if len(summary.Metrics) != 2 || summary.Metrics[0].Metric != "lengths.bytes" || summary.Metrics[1].Value != 1.5 {
+		t.Errorf("summary = %+v", summary.Metrics)
+	}
+}
+
+func TestComputeOutputs(t *testing.T) {
+	metric := lengths([]string{"bytes", "words"})
+	scores, err := metric.ComputeOutputs(context.Background(), []string{"a b"})
+	if err != nil || fmt.Sprint(scores["bytes"], scores["words"]) != "[3] [2]" {
+		t.Errorf("ComputeOutputs = %v, %v", scores, err)
+	}
+	if _, err := metric.Compute(context.Background(), []string{"a b"}); err == nil {
+		t.Error("Compute returned scores for a metric without a score of its own")
+	}
+
+	own := lengths([]string{"", "bytes", "words"})
+	if scores, err := own.Compute(context.Background(), []string{"a b"}); err != nil || fmt.Sprint(scores) != "[1]" {
+		t.Errorf("Compute = %v, %v, want the own score", scores, err)
+	}
+}
+
+func TestOutputColumnsErrors(t *testing.T) {
+	outputs := []string{"a", "b"}
+	tests := []struct {
+		name   string
+		scores map[string][]float64
+	}{
+		{"no scores", nil},
+		{"missing output", map[string][]float64{"a": {1}}},
+		{"undeclared output", map[string][]float64{"a": {1}, "b": {1}, "c": {1}}},
+	}
+	for _, tt := range tests {
+		if _, err := outputColumns(outputs, tt.scores, nil); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+
+	// Scores returned along with instance errors are kept
+	columns, err := outputColumns(outputs, map[string][]float64{"a": {1}, "b": {2}}, InstanceErrors{0: errTest})
+	if err == nil || len(columns) != 2 || columns[1][0] != 2 {
+		t.Errorf("columns = %v, %v", columns, err)
+	}
+}
+
+func TestOutputAggregators(t *testing.T) {
+	metric := lengths([]string{"bytes", "words"}).WithAggregator(MaxAggregator).WithOutputAggregator("bytes", SumAggregator)
+	if metric.OutputAggregator("bytes").Name != "sum" || metric.OutputAggregator("words").Name != "max" {
+		t.Errorf("aggregators of bytes and words = %s and %s, want sum and max",
+			metric.OutputAggregator("bytes").Name, metric.OutputAggregator("words").Name)
+	}
+
+	evaluation := NewPairwiseEvaluation("lengths", "", []PairwiseMetric{metric.ToPairwise(DifferenceScore)})
+	results, err := evaluation.Run(context.Background(), []Instance{{Reference: "a", Prediction: "a bc"}, {Reference: "a", Prediction: "def"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := evaluation.Summarize(results)
+	if bytes, _ := summary.Metric("lengths.bytes"); bytes.Aggregator != "sum" || bytes.Value != 5 {
+		t.Errorf("summary of lengths.bytes = %+v, want a sum of 5", bytes)
+	}
+}
+
+func TestDuplicateOutputs(t *testing.T) {
+	metric := lengths([]string{"bytes", "words", "bytes"})
+	if _, err := metric.ComputeOutputs(context.Background(), []string{"a"}); err == nil || !strings.Contains(err.Error(), `"bytes"`) {
+		t.Errorf("ComputeOutputs error = %v, want the duplicate output bytes", err)
+	}
+
+	evaluation := NewPointwiseEvaluation("lengths", "", []PointwiseMetric{metric})
+	if _, err := evaluation.Run(context.Background(), []string{"a"}); err == nil || !strings.Contains(err.Error(), "lengths.bytes") {
+		t.Errorf("Run error = %v, want the duplicate result key lengths.bytes", err)
+	}
+
+	clash := NewPointwiseEvaluation("clash", "", []PointwiseMetric{
+		lengths([]string{"bytes"}),
+		NewPointwiseMetric("lengths.bytes", "", func(ctx context.Context, predictions []string) ([]float64, error) {
+			return make([]float64, len(predictions)), nil
+		}),
+	})
+	clash.PartialFailures = true
+	if _, err := clash.Run(context.Background(), []string{"a"}); err == nil || !strings.Contains(err.Error(), "lengths.bytes") {
+		t.Errorf("Run error = %v, want the result key lengths.bytes shared by two metrics", err)
+	}
+}
@@ -0,0 +1,194 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// NewMultiReferencePairwiseMetric creates a new pairwise metric that natively scores each prediction
+// against a set of references. Used with single references, each set holds one reference.
+func NewMultiReferencePairwiseMetric(name, description string, compute MultiReferencePairwiseMetricFunc) PairwiseMetric {
+	return PairwiseMetric{
+		Name:         name,
+		Description:  description,
+		computeMulti: compute,
+	}
+}
+
+// WithReferenceReducer returns a copy of the metric that reduces the scores of a prediction against
+// each of its references with the given reducer, e.g. MaxAggregator, MeanAggregator or MinAggregator
+func (m PairwiseMetric) WithReferenceReducer(reducer Aggregator) PairwiseMetric {
+	m.ReferenceReducer = reducer
+	return m
+}
+
+// ComputeMultiReference executes the pairwise metric on predictions that each have a set of references.
+// Metrics created with NewPairwiseMetric score every reference-prediction pair in a single batch and
+// reduce the scores of each prediction with the metric's ReferenceReducer.
+func (m *PairwiseMetric) ComputeMultiReference(ctx context.Context, references [][]string, predictions []string) ([]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(references) == 0 {
+		return nil, fmt.Errorf("no references provided")
+	}
+
+	if len(references) != len(predictions) {
+		return nil, fmt.Errorf("number of references (%d) does not match number of predictions (%d)",
+			len(references), len(predictions))
+	}
+
+	if m.outputs != nil {
+		return m.ownScores(m.computeColumns(ctx, nil, references, predictions))
+	}
+	if m.computeMulti != nil {
+		return m.computeMulti(ctx, references, predictions)
+	}
+
+	columns, err := m.computeFlattened(ctx, references, predictions, 1, func(ctx context.Context, references, predictions []string) ([][]float64, error) {
+		scores, err := m.compute(ctx, references, predictions)
+		if scores == nil {
+			return nil, err
+		}
+		return [][]float64{scores}, err
+	})
+	if columns == nil {
+		return nil, err
+	}
+	return columns[0], err
+}
+
+// computeFlattened scores every reference-prediction pair in a single batch with compute, which returns the
+// given number of score columns, and reduces the scores of each prediction with the metric's ReferenceReducer.
+// Every output of a prediction comes from the reference its own score was reduced to. Outputs are reduced on
+// their own for metrics without a score of their own and for reducers without Select, e.g. the mean.
+func (m *PairwiseMetric) computeFlattened(ctx context.Context, references [][]string, predictions []string, outputs int,
+	compute func(ctx context.Context, references, predictions []string) ([][]float64, error)) ([][]float64, error) {
+	// Flatten every reference-prediction pair into a single batch, an instance without
+	// references is scored against an empty reference
+	var flatReferences, flatPredictions []string
+	owners := make([]int, 0, len(predictions))
+	for i, referenceSet := range references {
+		if len(referenceSet) == 0 {
+			referenceSet = []string{""}
+		}
+		for _, reference := range referenceSet {
+			flatReferences = append(flatReferences, reference)
+			flatPredictions = append(flatPredictions, predictions[i])
+			owners = append(owners, i)
+		}
+	}
+
+	// Expose the instance of every flattened pair to the metric function
+	if instances, ok := InstancesFromContext(ctx); ok && len(instances) == len(predictions) {
+		flatInstances := make([]Instance, len(owners))
+		for f, i := range owners {
+			flatInstances[f] = instances[i]
+			flatInstances[f].Reference = flatReferences[f]
+			flatInstances[f].References = nil
+		}
+		ctx = withInstances(ctx, flatInstances)
+	}
+
+	flatCtx, recorder := recordDetails(ctx)
+	flatColumns, err := compute(flatCtx, flatReferences, flatPredictions)
+	attachPairDetails(ctx, owners, recorder.collected())
+	var flatErrs InstanceErrors
+	if err != nil && (!errors.As(err, &flatErrs) || checkColumns(flatColumns, outputs, len(flatReferences)) != nil) {
+		return nil, err
+	}
+	if err == nil {
+		if err := checkColumns(flatColumns, outputs, len(flatReferences)); err != nil {
+			return nil, fmt.Errorf("flattened reference-prediction pairs: %w", err)
+		}
+	}
+
+	// An instance fails when any of its pairs failed
+	var failed InstanceErrors
+	for f, flatErr := range flatErrs {
+		if f < 0 || f >= len(owners) || flatErr == nil {
+			continue
+		}
+		if failed == nil {
+			failed = make(InstanceErrors)
+		}
+		if _, ok := failed[owners[f]]; !ok {
+			failed[owners[f]] = flatErr
+		}
+	}
+
+	reducer := m.ReferenceReducer
+	if reducer.Aggregate == nil {
+		reducer = MaxAggregator
+	}
+
+	// The outputs of a prediction are all taken from the reference its own score was reduced to, so that
+	// e.g. a precision and a recall are those of the same reference
+	own := 0
+	if m.outputs != nil {
+		own = -1
+		for i, output := range m.outputs {
+			if output == "" {
+				own = i
+			}
+		}
+	}
+
+	columns := newColumns(outputs, len(predictions))
+	for start := 0; start < len(owners); {
+		end := start
+		for end < len(owners) && owners[end] == owners[start] {
+			end++
+		}
+		if _, ok := failed[owners[start]]; !ok {
+			best := -1
+			if own >= 0 && reducer.Select != nil {
+				best = reducer.Select(flatColumns[own][start:end])
+			}
+			for c, flatScores := range flatColumns {
+				if best >= 0 {
+					columns[c][owners[start]] = flatScores[start+best]
+				} else {
+					columns[c][owners[start]] = reducer.Aggregate(flatScores[start:end])
+				}
+			}
+		}
+		start = end
+	}
+
+	if len(failed) > 0 {
+		return columns, failed
+	}
+	return columns, nil
+}
+
+// attachPairDetails attaches the details attached to each flattened reference-prediction pair to its instance.
+// The details of instances scored against several references are listed under "references", in their order.
+func attachPairDetails(ctx context.Context, owners []int, details map[int]Details) {
+	if len(details) == 0 {
+		return
+	}
+
+	for start := 0; start < len(owners); {
+		end := start
+		for end < len(owners) && owners[end] == owners[start] {
+			end++
+		}
+		if end-start == 1 {
+			AttachDetails(ctx, owners[start], details[start])
+		} else {
+			pairDetails := make([]Details, end-start)
+			found := false
+			for f := start; f < end; f++ {
+				pairDetails[f-start] = details[f]
+				found = found || details[f] != nil
+			}
+			if found {
+				AttachDetails(ctx, owners[start], Details{"references": pairDetails})
+			}
+		}
+		start = end
+	}
+}
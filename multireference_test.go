@@ -0,0 +1,238 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// unigramOverlap returns a multi-output metric reporting the F1, precision and recall of the shared words
+func unigramOverlap() PairwiseMetric {
+	return NewMultiOutputPairwiseMetric("overlap", "", []string{"", "precision", "recall"},
+		func(ctx context.Context, references, predictions []string) (map[string][]float64, error) {
+			f1s := make([]float64, len(predictions))
+			precisions := make([]float64, len(predictions))
+			recalls := make([]float64, len(predictions))
+			for i := range predictions {
+				referenceWords, predictionWords := strings.Fields(references[i]), strings.Fields(predictions[i])
+				shared := 0
+				for _, word := range predictionWords {
+					if strings.Contains(" "+references[i]+" ", " "+word+" ") {
+						shared++
+					}
+				}
+				if shared == 0 {
+					continue
+				}
+				precisions[i] = float64(shared) / float64(len(predictionWords))
+				recalls[i] = float64(shared) / float64(len(referenceWords))
+				f1s[i] = 2 * precisions[i] * recalls[i] / (precisions[i] + recalls[i])
+			}
+			return map[string][]float64{"": f1s, "precision": precisions, "recall": recalls}, nil
+		},
+	)
+}
+
+func TestMultiReferenceOutputsShareReference(t *testing.T) {
+	references := [][]string{{"a b c d e f", "a"}}
+	predictions := []string{"a b"}
+
+	tests := []struct {
+		name    string
+		reducer Aggregator
+		want    map[string]float64
+	}{
+		// The second reference has the best F1, 2/3, with a precision of 1/2 and a recall of 1
+		{"max", MaxAggregator, map[string]float64{"overlap": 2.0 / 3, "overlap.precision": 0.5, "overlap.recall": 1}},
+		// The first reference has the worst F1, 1/2, with a precision of 1 and a recall of 1/3
+		{"min", MinAggregator, map[string]float64{"overlap": 0.5, "overlap.precision": 1, "overlap.recall": 1.0 / 3}},
+		// The mean blends both references, each output is averaged on its own
+		{"mean", MeanAggregator, map[string]float64{"overlap": 7.0 / 12, "overlap.precision": 0.75, "overlap.recall": 2.0 / 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluation := NewPairwiseEvaluation("test", "", []PairwiseMetric{unigramOverlap().WithReferenceReducer(tt.reducer)})
+			results, err := evaluation.Run(context.Background(), []Instance{{
+				Reference:  references[0][0],
+				References: references[0][1:],
+				Prediction: predictions[0],
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, want := range tt.want {
+				if got := results[0].MetricResults[key]; !closeTo(got, want) {
+					t.Errorf("%s = %g, want %g", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiReferenceMeanMatchingOneReference(t *testing.T) {
+	// The references score 0, 0.5 and 1, so their mean equals the score of the second one,
+	// whose other output must not be taken in place of the mean of all three
+	scores := map[string]float64{"none": 0, "half": 0.5, "full": 1}
+	weights := map[string]float64{"none": 10, "half": 20, "full": 40}
+	metric := NewMultiOutputPairwiseMetric("match", "", []string{"", "weight"},
+		func(ctx context.Context, references, predictions []string) (map[string][]float64, error) {
+			outputs := map[string][]float64{"": make([]float64, len(references)), "weight": make([]float64, len(references))}
+			for i, reference := range references {
+				outputs[""][i] = scores[reference]
+				outputs["weight"][i] = weights[reference]
+			}
+			return outputs, nil
+		},
+	)
+
+	tests := []struct {
+		reducer Aggregator
+		want    map[string]float64
+	}{
+		{MeanAggregator, map[string]float64{"match": 0.5, "match.weight": 70.0 / 3}},
+		{MaxAggregator, map[string]float64{"match": 1, "match.weight": 40}},
+		{MinAggregator, map[string]float64{"match": 0, "match.weight": 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reducer.Name, func(t *testing.T) {
+			evaluation := NewPairwiseEvaluation("test", "", []PairwiseMetric{metric.WithReferenceReducer(tt.reducer)})
+			results, err := evaluation.Run(context.Background(), []Instance{{Reference: "none", References: []string{"half", "full"}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, want := range tt.want {
+				if got := results[0].MetricResults[key]; !closeTo(got, want) {
+					t.Errorf("%s = %g, want %g", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestComputeMultiReference(t *testing.T) {
+	exact := NewPairwiseMetric("exact", "", func(ctx context.Context, references, predictions []string) ([]float64, error) {
+		scores := make([]float64, len(predictions))
+		for i := range predictions {
+			if references[i] == predictions[i] {
+				scores[i] = 1
+			}
+		}
+		return scores, nil
+	})
+
+	scores, err := exact.ComputeMultiReference(context.Background(), [][]string{{"x", "y"}, {"x", "z"}, {}}, []string{"y", "y", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{1, 0, 1}; !equalScores(scores, want) {
+		t.Errorf("max scores = %v, want %v", scores, want)
+	}
+
+	mean := exact.WithReferenceReducer(MeanAggregator)
+	scores, err = mean.ComputeMultiReference(context.Background(), [][]string{{"x", "y"}}, []string{"y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{0.5}; !equalScores(scores, want) {
+		t.Errorf("mean scores = %v, want %v", scores, want)
+	}
+}
+
+func TestComputeMultiReferenceFailures(t *testing.T) {
+	// The pair of "x" and "bad" fails, failing the whole first instance
+	failing := NewPairwiseMetric("failing", "", func(ctx context.Context, references, predictions []string) ([]float64, error) {
+		scores := make([]float64, len(predictions))
+		errs := make(InstanceErrors)
+		for i := range predictions {
+			if references[i] == "bad" {
+				errs[i] = errTest
+			}
+		}
+		if len(errs) > 0 {
+			return scores, errs
+		}
+		return scores, nil
+	})
+
+	scores, err := failing.ComputeMultiReference(context.Background(), [][]string{{"x", "bad"}, {"y"}}, []string{"p", "q"})
+	var errs InstanceErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0] == nil || len(scores) != 2 {
+		t.Errorf("ComputeMultiReference = %v, %v, want the first instance to fail", scores, err)
+	}
+
+	if _, err := failing.ComputeMultiReference(context.Background(), [][]string{{"x"}}, []string{"p", "q"}); err == nil {
+		t.Error("ComputeMultiReference accepted more predictions than reference sets")
+	}
+}
+
+func TestNewMultiReferencePairwiseMetric(t *testing.T) {
+	// A native multi-reference metric sees every reference set whole
+	count := NewMultiReferencePairwiseMetric("count", "", func(ctx context.Context, references [][]string, predictions []string) ([]float64, error) {
+		scores := make([]float64, len(predictions))
+		for i := range references {
+			scores[i] = float64(len(references[i]))
+		}
+		return scores, nil
+	})
+
+	evaluation := NewPairwiseEvaluation("multi", "", []PairwiseMetric{count})
+	results, err := evaluation.Run(context.Background(), []Instance{
+		{Reference: "a", References: []string{"b", "c"}, Prediction: "p"},
+		{Reference: "a", Prediction: "p"},
+		{References: []string{"b"}, Prediction: "p"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{3, 1, 1} {
+		if got := results[i].MetricResults["count"]; got != want {
+			t.Errorf("result %d = %g, want %g references", i, got, want)
+		}
+	}
+}
+
+func TestRunMultiReferenceInstances(t *testing.T) {
+	// Flattened pairs expose their own reference through the context
+	reference := NewPairwiseMetric("reference", "", func(ctx context.Context, references, predictions []string) ([]float64, error) {
+		instances, ok := InstancesFromContext(ctx)
+		if !ok || len(instances) != len(references) {
+			return nil, fmt.Errorf("%d instances in context for %d pairs", len(instances), len(references))
+		}
+		scores := make([]float64, len(references))
+		for i, instance := range instances {
+			if instance.Reference != references[i] || instance.References != nil || instance.ID != "row" {
+				return nil, fmt.Errorf("pair %d sees instance %+v", i, instance)
+			}
+			scores[i] = float64(len(references[i]))
+		}
+		return scores, nil
+	})
+
+	evaluation := NewPairwiseEvaluation("multi", "", []PairwiseMetric{reference.WithReferenceReducer(MinAggregator)})
+	results, err := evaluation.Run(context.Background(), []Instance{{ID: "row", Reference: "abc", References: []string{"a", "ab"}, Prediction: "p"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := results[0].MetricResults["reference"]; got != 1 {
+		t.Errorf("reference = %g, want the shortest reference length 1", got)
+	}
+}
+
+func TestAllReferences(t *testing.T) {
+	tests := []struct {
+		instance Instance
+		want     []string
+	}{
+		{Instance{Reference: "a"}, []string{"a"}},
+		{Instance{}, []string{""}},
+		{Instance{Reference: "a", References: []string{"b", "c"}}, []string{"a", "b", "c"}},
+		{Instance{References: []string{"b", "c"}}, []string{"b", "c"}},
+	}
+	for _, tt := range tests {
+		if got := tt.instance.AllReferences(); fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("AllReferences of %+v = %q, want %q", tt.instance, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ParamType is the type of a metric parameter
+type ParamType int
+
+// Parameter types
+const (
+	IntParam ParamType = iota + 1
+	FloatParam
+	StringParam
+	BoolParam
+	// StringListParam is a list of strings, also accepted as a single "|" separated string
+	StringListParam
+)
+
+// String returns the name of the parameter type
+func (t ParamType) String() string {
+	switch t {
+	case IntParam:
+		return "int"
+	case FloatParam:
+		return "float"
+	case StringParam:
+		return "string"
+	case BoolParam:
+		return "bool"
+	case StringListParam:
+		return "[]string"
+	}
+	return fmt.Sprintf("ParamType(%d)", int(t))
+}
+
+// ParamSpec describes a parameter accepted by a metric factory
+type ParamSpec struct {
+	Name        string
+	Type        ParamType
+	Description string
+	// Default is the value of the parameter when it is not given, converted to the type of the spec like given values
+	Default any
+	// Required rejects parameter sets missing the parameter, Default is ignored
+	Required bool
+}
+
+// Params holds the validated parameters of a metric, each converted to the Go type of its spec:
+// int, float64, string, bool or []string
+type Params map[string]any
+
+// Int returns an integer parameter, or 0 when it is not set
+func (p Params) Int(name string) int {
+	value, _ := p[name].(int)
+	return value
+}
+
+// Float returns a float parameter, or 0 when it is not set
+func (p Params) Float(name string) float64 {
+	value, _ := p[name].(float64)
+	return value
+}
+
+// String returns a string parameter, or "" when it is not set
+func (p Params) String(name string) string {
+	value, _ := p[name].(string)
+	return value
+}
+
+// Bool returns a boolean parameter, or false when it is not set
+func (p Params) Bool(name string) bool {
+	value, _ := p[name].(bool)
+	return value
+}
+
+// Strings returns a string list parameter, or nil when it is not set
+func (p Params) Strings(name string) []string {
+	value, _ := p[name].([]string)
+	return value
+}
+
+// ParamError reports an invalid parameter of a metric
+type ParamError struct {
+	Metric string
+	Param  string
+	Err    error
+}
+
+// Error implements the error interface
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("metric %s: parameter %s: %v", e.Metric, e.Param, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// MetricFactory creates the metrics registered under a name from their parameters.
+// Exactly one of NewPairwise and NewPointwise is set.
+type MetricFactory struct {
+	Name        string
+	Description string
+	Params      []ParamSpec
+	// NewPairwise creates a metric comparing references and predictions
+	NewPairwise func(params Params) (PairwiseMetric, error)
+	// NewPointwise creates a metric evaluating predictions on their own
+	NewPointwise func(params Params) (PointwiseMetric, error)
+}
+
+// Pairwise reports whether the factory creates pairwise metrics
+func (f MetricFactory) Pairwise() bool {
+	return f.NewPairwise != nil
+}
+
+// ParseParams validates raw parameters against the factory's specs, converting them to the types of their specs
+// and filling in defaults. Numbers, booleans and lists may also be given as strings, e.g. from command-line flags.
+func (f MetricFactory) ParseParams(raw map[string]any) (Params, error) {
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := f.param(name); !ok {
+			return nil, &ParamError{f.Name, name, errors.New("unknown parameter")}
+		}
+	}
+
+	params := make(Params, len(f.Params))
+	for _, spec := range f.Params {
+		value, ok := raw[spec.Name]
+		if !ok {
+			if spec.Required {
+				return nil, &ParamError{f.Name, spec.Name, errors.New("missing required parameter")}
+			}
+			if spec.Default != nil {
+				converted, err := convertParam(spec.Type, spec.Default)
+				if err != nil {
+					return nil, &ParamError{f.Name, spec.Name, fmt.Errorf("invalid default: %w", err)}
+				}
+				params[spec.Name] = converted
+			}
+			continue
+		}
+
+		converted, err := convertParam(spec.Type, value)
+		if err != nil {
+			return nil, &ParamError{f.Name, spec.Name, err}
+		}
+		params[spec.Name] = converted
+	}
+	return params, nil
+}
+
+func (f MetricFactory) param(name string) (ParamSpec, bool) {
+	for _, spec := range f.Params {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return ParamSpec{}, false
+}
+
+// convertParam converts a raw parameter value, as decoded from JSON or YAML or given as a string, to the Go type of t
+func convertParam(t ParamType, value any) (any, error) {
+	switch t {
+	case IntParam:
+		switch v := value.(type) {
+		case int:
+			return v, nil
+		case float64:
+			if v == math.Trunc(v) {
+				return int(v), nil
+			}
+		case string:
+			if parsed, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				return parsed, nil
+			}
+		}
+		return nil, fmt.Errorf("expected an integer, got %v", value)
+	case FloatParam:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case string:
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return parsed, nil
+			}
+		}
+		return nil, fmt.Errorf("expected a number, got %v", value)
+	case StringParam:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("expected a string, got %v", value)
+	case BoolParam:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if parsed, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return parsed, nil
+			}
+		}
+		return nil, fmt.Errorf("expected a boolean, got %v", value)
+	case StringListParam:
+		switch v := value.(type) {
+		case []string:
+			// Copied so that factories cannot modify the caller's slice, e.g. a shared default
+			return append([]string(nil), v...), nil
+		case string:
+			items := strings.Split(v, "|")
+			for i, item := range items {
+				items[i] = strings.TrimSpace(item)
+			}
+			return items, nil
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				text, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected a list of strings, item %d is %v", i, item)
+				}
+				items[i] = text
+			}
+			return items, nil
+		}
+		return nil, fmt.Errorf("expected a list of strings, got %v", value)
+	}
+	return nil, fmt.Errorf("unsupported parameter type %v", t)
+}
+
+// Registry maps metric names to their factories, it is safe for concurrent use
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]MetricFactory
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]MetricFactory)}
+}
+
+// DefaultRegistry holds the built-in metrics of the metrics package and the metrics registered with Register
+var DefaultRegistry = NewRegistry()
+
+// Register adds a factory to DefaultRegistry, it panics when the factory is invalid or its name is taken.
+// It is meant to be called from the init function of packages providing metrics.
+func Register(factory MetricFactory) {
+	if err := DefaultRegistry.Register(factory); err != nil {
+		panic(err)
+	}
+}
+
+// Register adds a factory to the registry, failing when the factory is invalid or its name is taken
+func (r *Registry) Register(factory MetricFactory) error {
+	if strings.TrimSpace(factory.Name) == "" {
+		return errors.New("metric factory has no name")
+	}
+	if (factory.NewPairwise == nil) == (factory.NewPointwise == nil) {
+		return fmt.Errorf("metric factory %s must set exactly one of NewPairwise and NewPointwise", factory.Name)
+	}
+	for i, spec := range factory.Params {
+		if spec.Default != nil {
+			if _, err := convertParam(spec.Type, spec.Default); err != nil {
+				return fmt.Errorf("metric factory %s: default of parameter %s: %w", factory.Name, spec.Name, err)
+			}
+		}
+		for _, other := range factory.Params[:i] {
+			if other.Name == spec.Name {
+				return fmt.Errorf("metric factory %s declares parameter %s twice", factory.Name, spec.Name)
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.factories[factory.Name]; ok {
+		return fmt.Errorf("metric %s is already registered", factory.Name)
+	}
+	r.factories[factory.Name] = factory
+	return nil
+}
+
+// Lookup returns the factory registered under name
+func (r *Registry) Lookup(name string) (MetricFactory, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	factory, ok := r.factories[name]
+	return factory, ok
+}
+
+// Factories returns every registered factory, sorted by name
+func (r *Registry) Factories() []MetricFactory {
+	r.mu.RLock()
+	factories := make([]MetricFactory, 0, len(r.factories))
+	for _, factory := range r.factories {
+		factories = append(factories, factory)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(factories, func(i, j int) bool { return factories[i].Name < factories[j].Name })
+	return factories
+}
+
+// NewPairwise creates the pairwise metric registered under name from raw parameters
+func (r *Registry) NewPairwise(name string, raw map[string]any) (PairwiseMetric, error) {
+	factory, params, err := r.prepare(name, raw)
+	if err != nil {
+		return PairwiseMetric{}, err
+	}
+	if factory.NewPairwise == nil {
+		return PairwiseMetric{}, fmt.Errorf("metric %s is pointwise, convert it with ToPairwise", name)
+	}
+	return factory.NewPairwise(params)
+}
+
+// NewPointwise creates the pointwise metric registered under name from raw parameters
+func (r *Registry) NewPointwise(name string, raw map[string]any) (PointwiseMetric, error) {
+	factory, params, err := r.prepare(name, raw)
+	if err != nil {
+		return PointwiseMetric{}, err
+	}
+	if factory.NewPointwise == nil {
+		return PointwiseMetric{}, fmt.Errorf("metric %s compares references and predictions and cannot run in pointwise mode", name)
+	}
+	return factory.NewPointwise(params)
+}
+
+func (r *Registry) prepare(name string, raw map[string]any) (MetricFactory, Params, error) {
+	factory, ok := r.Lookup(name)
+	if !ok {
+		return MetricFactory{}, nil, fmt.Errorf("unknown metric %q", name)
+	}
+	params, err := factory.ParseParams(raw)
+	if err != nil {
+		return MetricFactory{}, nil, err
+	}
+	return factory, params, nil
+}
@@ -0,0 +1,107 @@
+package eval
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseParams(t *testing.T) {
+	factory := MetricFactory{
+		Name: "test",
+		Params: []ParamSpec{
+			{Name: "n", Type: IntParam, Default: 4},
+			{Name: "w", Type: FloatParam, Default: 2},
+			{Name: "mode", Type: StringParam, Default: "exp"},
+			{Name: "lower", Type: BoolParam},
+			{Name: "words", Type: StringListParam, Default: []string{"a", "b"}},
+		},
+		NewPairwise: func(Params) (PairwiseMetric, error) { return PairwiseMetric{}, nil },
+	}
+
+	tests := []struct {
+		name string
+		raw  map[string]any
+		want Params
+	}{
+		{
+			name: "defaults",
+			raw:  nil,
+			want: Params{"n": 4, "w": 2.0, "mode": "exp", "words": []string{"a", "b"}},
+		},
+		{
+			name: "given values",
+			raw:  map[string]any{"n": 2.0, "w": "0.5", "lower": "true", "words": "x | y"},
+			want: Params{"n": 2, "w": 0.5, "mode": "exp", "lower": true, "words": []string{"x", "y"}},
+		},
+		{
+			name: "decoded list",
+			raw:  map[string]any{"words": []any{"c"}},
+			want: Params{"n": 4, "w": 2.0, "mode": "exp", "words": []string{"c"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := factory.ParseParams(tt.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(params, tt.want) {
+				t.Errorf("ParseParams(%v) = %v, want %v", tt.raw, params, tt.want)
+			}
+		})
+	}
+
+	params, err := factory.ParseParams(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := params.Float("w"); w != 2 {
+		t.Errorf("Float(w) = %g, want the default 2", w)
+	}
+	params.Strings("words")[0] = "changed"
+	if factory.Params[4].Default.([]string)[0] != "a" {
+		t.Error("modifying a list parameter changed the default of its spec")
+	}
+}
+
+func TestParseParamsErrors(t *testing.T) {
+	factory := MetricFactory{
+		Name: "test",
+		Params: []ParamSpec{
+			{Name: "n", Type: IntParam, Required: true},
+		},
+	}
+	for _, raw := range []map[string]any{
+		{},
+		{"n": 1.5},
+		{"n": 1, "unknown": true},
+	} {
+		var paramErr *ParamError
+		if _, err := factory.ParseParams(raw); !errors.As(err, &paramErr) {
+			t.Errorf("ParseParams(%v) error = %v, want a *ParamError", raw, err)
+		}
+	}
+}
+
+func TestRegisterRejectsInvalidFactories(t *testing.T) {
+	newPairwise := func(Params) (PairwiseMetric, error) { return PairwiseMetric{}, nil }
+	for _, factory := range []MetricFactory{
+		{Name: "", NewPairwise: newPairwise},
+		{Name: "none"},
+		{Name: "bad_default", NewPairwise: newPairwise, Params: []ParamSpec{{Name: "n", Type: IntParam, Default: "x"}}},
+		{Name: "twice", NewPairwise: newPairwise, Params: []ParamSpec{{Name: "n", Type: IntParam}, {Name: "n", Type: IntParam}}},
+	} {
+		if err := NewRegistry().Register(factory); err == nil {
+			t.Errorf("Register(%q) succeeded, want an error", factory.Name)
+		}
+	}
+
+	registry := NewRegistry()
+	if err := registry.Register(MetricFactory{Name: "m", NewPairwise: newPairwise}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(MetricFactory{Name: "m", NewPairwise: newPairwise}); err == nil {
+		t.Error("registering a name twice succeeded, want an error")
+	}
+}
@@ -0,0 +1,214 @@
+package eval
+
+import "sort"
+
+// defaultTopRegressions is the number of regressed instances reported per metric by default
+const defaultTopRegressions = 10
+
+// DiffOptions configures the regression comparison of a run against a baseline run
+type DiffOptions struct {
+	// Aggregators reduces the scores of each metric on both sides, metrics missing from it use MeanAggregator.
+	// Pass the aggregators the baseline was summarized with, e.g. those of a stored run, to compare like with like.
+	Aggregators map[string]Aggregator
+	// LowerIsBetter lists the metrics whose scores are better when lower, e.g. error rates or edit distances,
+	// whose rising scores are regressions. Higher scores are better for every other metric.
+	LowerIsBetter map[string]bool
+	// TopRegressions is the number of regressed instances reported per metric,
+	// 0 means 10 and a negative value reports every regressed instance
+	TopRegressions int
+}
+
+// InstanceDelta holds the change of the score of one instance between a baseline and a current run
+type InstanceDelta struct {
+	ID string
+	// Index is the position of the instance in the current run
+	Index    int
+	Baseline float64
+	Current  float64
+	// Delta is Current minus Baseline
+	Delta float64
+}
+
+// MetricDelta holds the change of a metric between a baseline and a current run
+type MetricDelta struct {
+	Metric string
+	// Aggregator is the name of the aggregator that produced Baseline and Current
+	Aggregator string
+	Baseline   float64
+	Current    float64
+	// Delta is Current minus Baseline
+	Delta float64
+	// Improved, Regressed and Unchanged count the paired instances whose score got better, worse or stayed the same
+	Improved  int
+	Regressed int
+	Unchanged int
+	// Regressions lists the paired instances whose score got worse the most, largest regression first
+	Regressions []InstanceDelta
+}
+
+// InstanceError is a metric failure of one instance in the current run
+type InstanceError struct {
+	ID string
+	// Index is the position of the instance in the current run
+	Index  int
+	Metric string
+	Err    error
+}
+
+// RunDiff holds the regression comparison of a current run against a baseline run.
+// Higher scores are considered better unless DiffOptions.LowerIsBetter lists the metric.
+type RunDiff struct {
+	// Metrics holds the change of every metric present in both runs, sorted by name
+	Metrics []MetricDelta
+	// NewErrors lists the failures of paired instances whose metric did not fail in the baseline
+	NewErrors []InstanceError
+	// Added and Removed list the IDs of the instances only found in the current or in the baseline run.
+	// They are only populated when instances are matched by ID.
+	Added   []string
+	Removed []string
+}
+
+// Metric returns the change of the named metric
+func (d RunDiff) Metric(name string) (MetricDelta, bool) {
+	for _, metric := range d.Metrics {
+		if metric.Metric == name {
+			return metric, true
+		}
+	}
+	return MetricDelta{}, false
+}
+
+// DiffPairwise compares the results of a pairwise run against those of a baseline run.
+// Results are paired by instance ID when every instance has one, by position otherwise.
+func DiffPairwise(baseline, current []PairwiseResult, opts DiffOptions) (RunDiff, error) {
+	return diffRuns(pairwiseRows(baseline), pairwiseRows(current), opts)
+}
+
+// DiffPointwise compares the results of a pointwise run against those of a baseline run.
+// Results are paired by instance ID when every instance has one, by position otherwise.
+func DiffPointwise(baseline, current []PointwiseResult, opts DiffOptions) (RunDiff, error) {
+	return diffRuns(pointwiseRows(baseline), pointwiseRows(current), opts)
+}
+
+func diffRuns(baseline, current []row, opts DiffOptions) (RunDiff, error) {
+	if opts.TopRegressions == 0 {
+		opts.TopRegressions = defaultTopRegressions
+	}
+
+	pairedBaseline, pairedCurrent, pairs, err := pairRows(baseline, current)
+	if err != nil {
+		return RunDiff{}, err
+	}
+
+	var diff RunDiff
+	if hasIDs(baseline) && hasIDs(current) {
+		diff.Added, diff.Removed = unpairedIDs(baseline, current)
+	}
+
+	inBaseline := make(map[string]bool)
+	for _, name := range metricNames(baseline, nil) {
+		inBaseline[name] = true
+	}
+
+	for _, name := range metricNames(current, nil) {
+		if !inBaseline[name] {
+			continue
+		}
+
+		baselineScores, baselineNaNs, baselineErrs := metricScores(baseline, name)
+		currentScores, currentNaNs, currentErrs := metricScores(current, name)
+		baselineSummary := summarizeScores(name, baselineScores, baselineNaNs, baselineErrs, opts.Aggregators[name])
+		currentSummary := summarizeScores(name, currentScores, currentNaNs, currentErrs, opts.Aggregators[name])
+
+		delta := MetricDelta{
+			Metric:     name,
+			Aggregator: currentSummary.Aggregator,
+			Baseline:   baselineSummary.Value,
+			Current:    currentSummary.Value,
+			Delta:      currentSummary.Value - baselineSummary.Value,
+		}
+
+		lowerIsBetter := opts.LowerIsBetter[name]
+		var regressions []InstanceDelta
+		for i := range pairedCurrent {
+			baselineScore, okBaseline := pairedScore(pairedBaseline[i], name)
+			currentScore, okCurrent := pairedScore(pairedCurrent[i], name)
+			if !okBaseline || !okCurrent {
+				continue
+			}
+			better, worse := currentScore > baselineScore, currentScore < baselineScore
+			if lowerIsBetter {
+				better, worse = worse, better
+			}
+			switch {
+			case better:
+				delta.Improved++
+			case worse:
+				delta.Regressed++
+				regressions = append(regressions, InstanceDelta{
+					ID:       pairedCurrent[i].id,
+					Index:    pairs[i][1],
+					Baseline: baselineScore,
+					Current:  currentScore,
+					Delta:    currentScore - baselineScore,
+				})
+			default:
+				delta.Unchanged++
+			}
+		}
+		sort.SliceStable(regressions, func(i, j int) bool {
+			if lowerIsBetter {
+				return regressions[i].Delta > regressions[j].Delta
+			}
+			return regressions[i].Delta < regressions[j].Delta
+		})
+		if opts.TopRegressions > 0 && len(regressions) > opts.TopRegressions {
+			regressions = regressions[:opts.TopRegressions]
+		}
+		delta.Regressions = regressions
+
+		diff.Metrics = append(diff.Metrics, delta)
+	}
+
+	// Report failures of paired instances that did not fail in the baseline
+	for i := range pairedCurrent {
+		names := make([]string, 0, len(pairedCurrent[i].errors))
+		for name := range pairedCurrent[i].errors {
+			if _, failed := pairedBaseline[i].errors[name]; !failed {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			diff.NewErrors = append(diff.NewErrors, InstanceError{
+				ID:     pairedCurrent[i].id,
+				Index:  pairs[i][1],
+				Metric: name,
+				Err:    pairedCurrent[i].errors[name],
+			})
+		}
+	}
+
+	return diff, nil
+}
+
+// unpairedIDs returns the IDs only found in current and those only found in baseline, in the order of their run
+func unpairedIDs(baseline, current []row) (added, removed []string) {
+	baselineIDs := make(map[string]bool, len(baseline))
+	for _, r := range baseline {
+		baselineIDs[r.id] = true
+	}
+	currentIDs := make(map[string]bool, len(current))
+	for _, r := range current {
+		currentIDs[r.id] = true
+		if !baselineIDs[r.id] {
+			added = append(added, r.id)
+		}
+	}
+	for _, r := range baseline {
+		if !currentIDs[r.id] {
+			removed = append(removed, r.id)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,117 @@
+package eval
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiffPairwise(t *testing.T) {
+	result := func(id string, scores map[string]float64, errs MetricErrors) PairwiseResult {
+		return PairwiseResult{Instance: Instance{ID: id}, MetricResults: scores, Errors: errs}
+	}
+	baseline := []PairwiseResult{
+		result("a", map[string]float64{"m": 0.5}, nil),
+		result("b", map[string]float64{"m": 0.8}, nil),
+		result("c", map[string]float64{"m": 0.2}, nil),
+		result("d", map[string]float64{"m": 1, "only_baseline": 1}, nil),
+	}
+	current := []PairwiseResult{
+		result("b", map[string]float64{"m": 0.4}, nil),
+		result("a", map[string]float64{"m": 0.9}, nil),
+		result("c", map[string]float64{"m": 0.2}, MetricErrors{"k": errTest}),
+		result("e", map[string]float64{"m": 0, "only_current": 1}, nil),
+	}
+
+	diff, err := DiffPairwise(baseline, current, DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Metrics) != 1 {
+		t.Fatalf("metrics = %+v, want m only", diff.Metrics)
+	}
+	m, ok := diff.Metric("m")
+	if !ok || m.Aggregator != "mean" || !closeTo(m.Baseline, 0.625) || !closeTo(m.Current, 0.375) || !closeTo(m.Delta, -0.25) {
+		t.Errorf("delta of m = %+v", m)
+	}
+	// Instances are paired by ID, d and e are only found on one side
+	if m.Improved != 1 || m.Regressed != 1 || m.Unchanged != 1 {
+		t.Errorf("improved, regressed, unchanged = %d, %d, %d, want 1 each", m.Improved, m.Regressed, m.Unchanged)
+	}
+	if len(m.Regressions) != 1 || m.Regressions[0].ID != "b" || m.Regressions[0].Index != 0 || !closeTo(m.Regressions[0].Delta, -0.4) {
+		t.Errorf("regressions = %+v, want b dropping by 0.4", m.Regressions)
+	}
+	if len(diff.NewErrors) != 1 || diff.NewErrors[0].ID != "c" || diff.NewErrors[0].Index != 2 || diff.NewErrors[0].Metric != "k" {
+		t.Errorf("new errors = %+v, want k failing on c", diff.NewErrors)
+	}
+	if fmt.Sprint(diff.Added, diff.Removed) != "[e] [d]" {
+		t.Errorf("added, removed = %v, %v, want [e], [d]", diff.Added, diff.Removed)
+	}
+}
+
+func TestDiffTopRegressions(t *testing.T) {
+	baseline := pointwiseScores("m", []float64{1, 1, 1, 1})
+	current := pointwiseScores("m", []float64{0.5, 0.9, 0.1, 1})
+
+	tests := []struct {
+		top  int
+		want []int
+	}{
+		{0, []int{2, 0, 1}},
+		{2, []int{2, 0}},
+		{-1, []int{2, 0, 1}},
+	}
+	for _, tt := range tests {
+		diff, err := DiffPointwise(baseline, current, DiffOptions{TopRegressions: tt.top})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var indices []int
+		for _, regression := range diff.Metrics[0].Regressions {
+			indices = append(indices, regression.Index)
+		}
+		if fmt.Sprint(indices) != fmt.Sprint(tt.want) {
+			t.Errorf("TopRegressions %d: regressed instances = %v, want %v", tt.top, indices, tt.want)
+		}
+		if diff.Added != nil || diff.Removed != nil {
+			t.Errorf("runs paired by position report added %v and removed %v", diff.Added, diff.Removed)
+		}
+	}
+}
+
+func TestDiffAggregators(t *testing.T) {
+	baseline := pointwiseScores("m", []float64{1, 2})
+	current := pointwiseScores("m", []float64{1, 4})
+	diff, err := DiffPointwise(baseline, current, DiffOptions{Aggregators: map[string]Aggregator{"m": SumAggregator}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := diff.Metrics[0]; m.Aggregator != "sum" || m.Baseline != 3 || m.Current != 5 || m.Delta != 2 {
+		t.Errorf("delta of m = %+v, want sums 3 and 5", m)
+	}
+}
+
+func TestDiffLowerIsBetter(t *testing.T) {
+	baseline := pointwiseScores("distance", []float64{2, 2, 2, 2})
+	current := pointwiseScores("distance", []float64{1, 5, 3, 2})
+	diff, err := DiffPointwise(baseline, current, DiffOptions{LowerIsBetter: map[string]bool{"distance": true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := diff.Metrics[0]
+	if m.Improved != 1 || m.Regressed != 2 || m.Unchanged != 1 {
+		t.Errorf("delta of distance = %+v, want 1 improved, 2 regressed and 1 unchanged", m)
+	}
+	if len(m.Regressions) != 2 || m.Regressions[0].Index != 1 || m.Regressions[0].Delta != 3 || m.Regressions[1].Index != 2 {
+		t.Errorf("regressions of distance = %+v, want instances 1 and 2, largest rise first", m.Regressions)
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	if _, err := DiffPointwise(pointwiseScores("m", []float64{1}), pointwiseScores("m", []float64{1, 2}), DiffOptions{}); err == nil {
+		t.Error("runs of different lengths without IDs were paired")
+	}
+	duplicate := []PairwiseResult{{Instance: Instance{ID: "a"}}, {Instance: Instance{ID: "a"}}}
+	if _, err := DiffPairwise(duplicate, duplicate, DiffOptions{}); err == nil {
+		t.Error("duplicate instance IDs were paired")
+	}
+}
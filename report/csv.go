@@ -0,0 +1,131 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// WriteCSV writes one row per instance with one column per metric, in the order of Run.Metrics.
+// Errored scores are left empty and their errors listed in a trailing errors column.
+func WriteCSV(w io.Writer, run Run) error {
+	metrics := run.Metrics()
+	hasErrors := false
+	for _, row := range run.Rows {
+		hasErrors = hasErrors || len(row.Errors) > 0
+	}
+
+	header := []string{"id"}
+	if run.Pairwise {
+		header = append(header, "reference")
+	}
+	header = append(header, "prediction", "tags")
+	header = append(header, metrics...)
+	if hasErrors {
+		header = append(header, "errors")
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range run.Rows {
+		record := []string{row.ID}
+		if run.Pairwise {
+			record = append(record, row.Reference)
+		}
+		record = append(record, row.Prediction, strings.Join(row.Tags, "|"))
+		for _, metric := range metrics {
+			score, ok := row.Scores[metric]
+			if _, failed := row.Errors[metric]; failed || !ok {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, strconv.FormatFloat(score, 'g', -1, 64))
+		}
+		if hasErrors {
+			record = append(record, formatErrors(row.Errors, metrics))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteSummaryCSV writes one row per metric of a run summary
+func WriteSummaryCSV(w io.Writer, summary eval.Summary) error {
+	percentiles := percentileNames(summary)
+
+	header := []string{"metric", "aggregator", "value", "count", "nan_count", "error_count", "mean", "median", "stddev", "min", "max"}
+	header = append(header, percentiles...)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, metric := range summary.Metrics {
+		record := []string{
+			metric.Metric,
+			metric.Aggregator,
+			strconv.FormatFloat(metric.Value, 'g', -1, 64),
+			strconv.Itoa(metric.Count),
+			strconv.Itoa(metric.NaNCount),
+			strconv.Itoa(metric.ErrorCount),
+			strconv.FormatFloat(metric.Mean, 'g', -1, 64),
+			strconv.FormatFloat(metric.Median, 'g', -1, 64),
+			strconv.FormatFloat(metric.StdDev, 'g', -1, 64),
+			strconv.FormatFloat(metric.Min, 'g', -1, 64),
+			strconv.FormatFloat(metric.Max, 'g', -1, 64),
+		}
+		for _, name := range percentiles {
+			record = append(record, strconv.FormatFloat(metric.Percentiles[name], 'g', -1, 64))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatErrors lists errors as "metric: error" pairs in metric order
+func formatErrors(errs map[string]string, metrics []string) string {
+	parts := make([]string, 0, len(errs))
+	for _, metric := range metrics {
+		if err, ok := errs[metric]; ok {
+			parts = append(parts, metric+": "+err)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// percentileNames returns the percentiles reported in a summary, in increasing order
+func percentileNames(summary eval.Summary) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, metric := range summary.Metrics {
+		for name := range metric.Percentiles {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		a, _ := strconv.ParseFloat(strings.TrimPrefix(names[i], "p"), 64)
+		b, _ := strconv.ParseFloat(strings.TrimPrefix(names[j], "p"), 64)
+		return a < b
+	})
+	return names
+}
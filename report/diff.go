@@ -0,0 +1,87 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// WriteDiffMarkdown writes the comparison of a run against a baseline as Markdown: a table of the
+// change of every metric followed by the most regressed instances, the new errors and the
+// instances added or removed since the baseline
+func WriteDiffMarkdown(w io.Writer, diff eval.RunDiff) error {
+	var b strings.Builder
+	writeTableRow(&b, []string{"Metric", "Baseline", "Current", "Delta", "Improved", "Regressed", "Unchanged"})
+	writeTableRow(&b, []string{"---", "---:", "---:", "---:", "---:", "---:", "---:"})
+	for _, metric := range diff.Metrics {
+		writeTableRow(&b, []string{
+			escapeMarkdown(metric.Metric),
+			formatScore(metric.Baseline) + " (" + metric.Aggregator + ")",
+			formatScore(metric.Current),
+			formatDelta(metric.Delta),
+			strconv.Itoa(metric.Improved),
+			strconv.Itoa(metric.Regressed),
+			strconv.Itoa(metric.Unchanged),
+		})
+	}
+
+	for _, metric := range diff.Metrics {
+		if len(metric.Regressions) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### Regressions of %s\n\n", escapeMarkdown(metric.Metric))
+		writeTableRow(&b, []string{"Instance", "Baseline", "Current", "Delta"})
+		writeTableRow(&b, []string{"---", "---:", "---:", "---:"})
+		for _, regression := range metric.Regressions {
+			writeTableRow(&b, []string{
+				instanceLabel(regression.ID, regression.Index),
+				formatScore(regression.Baseline),
+				formatScore(regression.Current),
+				formatDelta(regression.Delta),
+			})
+		}
+	}
+
+	if len(diff.NewErrors) > 0 {
+		b.WriteString("\n### New errors\n\n")
+		writeTableRow(&b, []string{"Instance", "Metric", "Error"})
+		writeTableRow(&b, []string{"---", "---", "---"})
+		for _, failure := range diff.NewErrors {
+			writeTableRow(&b, []string{
+				instanceLabel(failure.ID, failure.Index),
+				escapeMarkdown(failure.Metric),
+				escapeMarkdown(failure.Err.Error()),
+			})
+		}
+	}
+
+	if len(diff.Added) > 0 {
+		fmt.Fprintf(&b, "\n%d instances added: %s\n", len(diff.Added), escapeMarkdown(strings.Join(diff.Added, ", ")))
+	}
+	if len(diff.Removed) > 0 {
+		fmt.Fprintf(&b, "\n%d instances removed: %s\n", len(diff.Removed), escapeMarkdown(strings.Join(diff.Removed, ", ")))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// instanceLabel names an instance by its ID, or by its 1-based position when it has none
+func instanceLabel(id string, index int) string {
+	if id != "" {
+		return escapeMarkdown(id)
+	}
+	return "#" + strconv.Itoa(index+1)
+}
+
+// formatDelta formats a score difference with an explicit sign
+func formatDelta(delta float64) string {
+	text := formatScore(delta)
+	if delta > 0 && text != "0" {
+		return "+" + text
+	}
+	return text
+}
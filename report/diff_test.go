@@ -0,0 +1,41 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	eval "github.com/snpu/eval-go"
+)
+
+func TestWriteDiffMarkdown(t *testing.T) {
+	diff := eval.RunDiff{
+		Metrics: []eval.MetricDelta{{
+			Metric: "m", Aggregator: "mean", Baseline: 0.5, Current: 0.75, Delta: 0.25, Improved: 2, Regressed: 1,
+			Regressions: []eval.InstanceDelta{{Index: 3, Baseline: 1, Current: 0.5, Delta: -0.5}},
+		}},
+		NewErrors: []eval.InstanceError{{ID: "x|y", Index: 0, Metric: "m", Err: errors.New("boom")}},
+		Added:     []string{"e"},
+		Removed:   []string{"d", "f"},
+	}
+	var b bytes.Buffer
+	if err := WriteDiffMarkdown(&b, diff); err != nil {
+		t.Fatal(err)
+	}
+	want := "| Metric | Baseline | Current | Delta | Improved | Regressed | Unchanged |\n" +
+		"| --- | ---: | ---: | ---: | ---: | ---: | ---: |\n" +
+		"| m | 0.5 (mean) | 0.75 | +0.25 | 2 | 1 | 0 |\n" +
+		"\n### Regressions of m\n\n" +
+		"| Instance | Baseline | Current | Delta |\n" +
+		"| --- | ---: | ---: | ---: |\n" +
+		"| #4 | 1 | 0.5 | -0.5 |\n" +
+		"\n### New errors\n\n" +
+		"| Instance | Metric | Error |\n" +
+		"| --- | --- | --- |\n" +
+		"| x\\|y | m | boom |\n" +
+		"\n1 instances added: e\n" +
+		"\n2 instances removed: d, f\n"
+	if b.String() != want {
+		t.Errorf("Markdown =\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,274 @@
+package report
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// histogramBins is the number of bars of each metric histogram
+const histogramBins = 20
+
+// maxDiffCells bounds the size of the word alignment computed for a side-by-side diff,
+// longer texts are shown without highlighting
+const maxDiffCells = 4_000_000
+
+//go:embed html.tmpl
+var htmlTemplate string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"score": formatScore,
+}).Parse(htmlTemplate))
+
+type htmlReport struct {
+	Name        string
+	Description string
+	Pairwise    bool
+	Instances   int
+	Metrics     []string
+	Summary     []eval.MetricSummary
+	Percentiles []string
+	Histograms  []htmlHistogram
+	Rows        []htmlRow
+	// HasDetails is set when metrics attached details to any instance
+	HasDetails bool
+}
+
+type htmlHistogram struct {
+	Metric string
+	Min    float64
+	Max    float64
+	Bars   []htmlBar
+}
+
+type htmlBar struct {
+	X, Y, Width, Height float64
+	Label               string
+}
+
+type htmlRow struct {
+	ID         string
+	Tags       string
+	Reference  []diffSegment
+	Prediction []diffSegment
+	References []string
+	Cells      []htmlCell
+	Errors     string
+	Details    []htmlDetails
+}
+
+type htmlCell struct {
+	Text  string
+	Value string
+	Error bool
+}
+
+// htmlDetails holds the details a metric attached to an instance, formatted as indented JSON
+type htmlDetails struct {
+	Metric string
+	JSON   string
+}
+
+// diffSegment is a run of words that is either shared by both texts or only found in one of them
+type diffSegment struct {
+	Text    string
+	Changed bool
+}
+
+// WriteHTML writes the run as a single self-contained HTML page with no external assets: a summary
+// table, a histogram per metric and a sortable per-instance table showing references and predictions
+// side by side with their differing words highlighted, along with the details attached by metrics.
+// Runs without a summary are summarized with the mean of every metric.
+func WriteHTML(w io.Writer, run Run) error {
+	summary := run.Summary
+	if summary == nil {
+		computed := eval.SummarizePairwise(run.PairwiseResults(), nil)
+		summary = &computed
+	}
+
+	metrics := run.Metrics()
+	page := htmlReport{
+		Name:        run.Name,
+		Description: run.Description,
+		Pairwise:    run.Pairwise,
+		Instances:   len(run.Rows),
+		Metrics:     metrics,
+		Summary:     summary.Metrics,
+		Percentiles: percentileNames(*summary),
+	}
+
+	for _, metric := range metrics {
+		var scores []float64
+		for _, row := range run.Rows {
+			score, ok := row.Scores[metric]
+			if _, failed := row.Errors[metric]; ok && !failed && !math.IsNaN(score) && !math.IsInf(score, 0) {
+				scores = append(scores, score)
+			}
+		}
+		if len(scores) > 0 {
+			page.Histograms = append(page.Histograms, newHistogram(metric, scores))
+		}
+	}
+
+	for _, row := range run.Rows {
+		htmlRow := htmlRow{
+			ID:         row.ID,
+			Tags:       strings.Join(row.Tags, ", "),
+			References: row.References,
+			Errors:     formatErrors(row.Errors, metrics),
+			Details:    formatDetails(row.Details),
+		}
+		page.HasDetails = page.HasDetails || len(htmlRow.Details) > 0
+		if run.Pairwise {
+			htmlRow.Reference, htmlRow.Prediction = diffWords(row.Reference, row.Prediction)
+		} else {
+			htmlRow.Prediction = []diffSegment{{Text: row.Prediction}}
+		}
+		for _, metric := range metrics {
+			score, ok := row.Scores[metric]
+			_, failed := row.Errors[metric]
+			switch {
+			case failed:
+				htmlRow.Cells = append(htmlRow.Cells, htmlCell{Text: "error", Error: true})
+			case !ok:
+				htmlRow.Cells = append(htmlRow.Cells, htmlCell{})
+			default:
+				htmlRow.Cells = append(htmlRow.Cells, htmlCell{Text: formatScore(score), Value: fmt.Sprint(score)})
+			}
+		}
+		page.Rows = append(page.Rows, htmlRow)
+	}
+
+	return reportTemplate.Execute(w, page)
+}
+
+// formatDetails formats the details of every metric as indented JSON, sorted by metric name
+func formatDetails(details eval.MetricDetails) []htmlDetails {
+	names := make([]string, 0, len(details))
+	for name := range details {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	formatted := make([]htmlDetails, len(names))
+	for i, name := range names {
+		text, err := json.MarshalIndent(details[name], "", "  ")
+		if err != nil {
+			text = []byte(fmt.Sprint(details[name]))
+		}
+		formatted[i] = htmlDetails{Metric: name, JSON: string(text)}
+	}
+	return formatted
+}
+
+// newHistogram bins scores into bars laid out in a 300x100 SVG viewport
+func newHistogram(metric string, scores []float64) htmlHistogram {
+	low, high := scores[0], scores[0]
+	for _, score := range scores {
+		low = math.Min(low, score)
+		high = math.Max(high, score)
+	}
+
+	counts := make([]int, histogramBins)
+	for _, score := range scores {
+		bin := 0
+		if high > low {
+			bin = int((score - low) / (high - low) * histogramBins)
+		}
+		if bin >= histogramBins {
+			bin = histogramBins - 1
+		}
+		counts[bin]++
+	}
+
+	largest := 0
+	for _, count := range counts {
+		if count > largest {
+			largest = count
+		}
+	}
+
+	histogram := htmlHistogram{Metric: metric, Min: low, Max: high}
+	width := 300.0 / histogramBins
+	for bin, count := range counts {
+		height := 100 * float64(count) / float64(largest)
+		binLow := low + (high-low)*float64(bin)/histogramBins
+		binHigh := low + (high-low)*float64(bin+1)/histogramBins
+		histogram.Bars = append(histogram.Bars, htmlBar{
+			X:      float64(bin) * width,
+			Y:      100 - height,
+			Width:  width - 1,
+			Height: height,
+			Label:  fmt.Sprintf("%s to %s: %d", formatScore(binLow), formatScore(binHigh), count),
+		})
+	}
+	return histogram
+}
+
+// diffWords aligns the words of two texts with their longest common subsequence, marking the
+// words found in only one of them as changed
+func diffWords(a, b string) ([]diffSegment, []diffSegment) {
+	wordsA, wordsB := strings.Fields(a), strings.Fields(b)
+	if len(wordsA)*len(wordsB) > maxDiffCells {
+		return []diffSegment{{Text: a}}, []diffSegment{{Text: b}}
+	}
+
+	// lengths[i][j] is the length of the longest common subsequence of wordsA[i:] and wordsB[j:]
+	lengths := make([][]int, len(wordsA)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(wordsB)+1)
+	}
+	for i := len(wordsA) - 1; i >= 0; i-- {
+		for j := len(wordsB) - 1; j >= 0; j-- {
+			if wordsA[i] == wordsB[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var segmentsA, segmentsB []diffSegment
+	i, j := 0, 0
+	for i < len(wordsA) || j < len(wordsB) {
+		switch {
+		case i < len(wordsA) && j < len(wordsB) && wordsA[i] == wordsB[j]:
+			segmentsA = appendWord(segmentsA, wordsA[i], false)
+			segmentsB = appendWord(segmentsB, wordsB[j], false)
+			i++
+			j++
+		case j == len(wordsB) || (i < len(wordsA) && lengths[i+1][j] >= lengths[i][j+1]):
+			segmentsA = appendWord(segmentsA, wordsA[i], true)
+			i++
+		default:
+			segmentsB = appendWord(segmentsB, wordsB[j], true)
+			j++
+		}
+	}
+	return segmentsA, segmentsB
+}
+
+// appendWord adds a word to the last segment when it has the same state, starting a new segment otherwise
+func appendWord(segments []diffSegment, word string, changed bool) []diffSegment {
+	if len(segments) > 0 {
+		last := &segments[len(segments)-1]
+		if last.Changed == changed {
+			last.Text += " " + word
+			return segments
+		}
+		word = " " + word
+		// Keep the separating space outside of highlighted segments
+		if changed {
+			last.Text += " "
+			word = word[1:]
+		}
+	}
+	return append(segments, diffSegment{Text: word, Changed: changed})
+}
@@ -0,0 +1,94 @@
+package report
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDiffWords(t *testing.T) {
+	tests := []struct {
+		a, b         string
+		wantA, wantB []diffSegment
+	}{
+		{
+			"the cat sat", "the cat sat",
+			[]diffSegment{{Text: "the cat sat"}},
+			[]diffSegment{{Text: "the cat sat"}},
+		},
+		{
+			"the cat sat down", "a cat sat",
+			[]diffSegment{{Text: "the", Changed: true}, {Text: " cat sat "}, {Text: "down", Changed: true}},
+			[]diffSegment{{Text: "a", Changed: true}, {Text: " cat sat"}},
+		},
+		{
+			"", "new words",
+			nil,
+			[]diffSegment{{Text: "new words", Changed: true}},
+		},
+	}
+	for _, tt := range tests {
+		gotA, gotB := diffWords(tt.a, tt.b)
+		if !equalSegments(gotA, tt.wantA) || !equalSegments(gotB, tt.wantB) {
+			t.Errorf("diffWords(%q, %q) = %+v, %+v, want %+v, %+v", tt.a, tt.b, gotA, gotB, tt.wantA, tt.wantB)
+		}
+	}
+}
+
+func equalSegments(a, b []diffSegment) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestNewHistogram(t *testing.T) {
+	histogram := newHistogram("m", []float64{0, 0, 0.5, 1})
+	if histogram.Min != 0 || histogram.Max != 1 || len(histogram.Bars) != histogramBins {
+		t.Fatalf("histogram = %+v", histogram)
+	}
+	// The maximum falls in the last bin, the tallest bar fills the viewport
+	first, middle, last := histogram.Bars[0], histogram.Bars[histogramBins/2], histogram.Bars[histogramBins-1]
+	if first.Height != 100 || middle.Height != 50 || last.Height != 50 || histogram.Bars[1].Height != 0 {
+		t.Errorf("bar heights = %g, %g, %g, want 100, 50, 50", first.Height, middle.Height, last.Height)
+	}
+	if first.Label != "0 to 0.05: 2" {
+		t.Errorf("first label = %q", first.Label)
+	}
+
+	constant := newHistogram("m", []float64{0.3, 0.3})
+	if constant.Bars[0].Height != 100 {
+		t.Errorf("constant scores are not binned together: %+v", constant.Bars[0])
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	run := testRun()
+	run.Rows[0].Prediction = "<script>alert(1)</script>"
+
+	var b bytes.Buffer
+	if err := WriteHTML(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+
+	if strings.Contains(page, "<script>alert(1)") {
+		t.Error("prediction is not escaped")
+	}
+	for _, want := range []string{"<title>qa", "Answers | scored", "exact: boom", "<svg"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+	// The page must work offline as a single file
+	external := regexp.MustCompile(`(?i)(src|href)\s*=\s*["']?(https?:)?//`)
+	if match := external.FindString(page); match != "" {
+		t.Errorf("page references an external asset: %s", match)
+	}
+}
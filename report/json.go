@@ -0,0 +1,248 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// number is a float that encodes NaN and infinities, which JSON cannot represent, as null
+type number float64
+
+// MarshalJSON implements json.Marshaler
+func (n number) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(float64(n)) || math.IsInf(float64(n), 0) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(float64(n))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading null as NaN
+func (n *number) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*n = number(math.NaN())
+		return nil
+	}
+	return json.Unmarshal(data, (*float64)(n))
+}
+
+type jsonRun struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Pairwise    bool         `json:"pairwise"`
+	Summary     *jsonSummary `json:"summary,omitempty"`
+	Results     []jsonRow    `json:"results"`
+}
+
+type jsonRow struct {
+	ID         string             `json:"id,omitempty"`
+	Reference  *string            `json:"reference,omitempty"`
+	References []string           `json:"references,omitempty"`
+	Prediction string             `json:"prediction"`
+	Metadata   map[string]string  `json:"metadata,omitempty"`
+	Tags       []string           `json:"tags,omitempty"`
+	Scores     map[string]number  `json:"scores"`
+	Errors     map[string]string  `json:"errors,omitempty"`
+	Details    eval.MetricDetails `json:"details,omitempty"`
+}
+
+type jsonSummary struct {
+	Name        string              `json:"name,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Instances   int                 `json:"instances"`
+	Metrics     []jsonMetricSummary `json:"metrics"`
+}
+
+type jsonMetricSummary struct {
+	Metric      string            `json:"metric"`
+	Aggregator  string            `json:"aggregator"`
+	Value       number            `json:"value"`
+	Count       int               `json:"count"`
+	NaNCount    int               `json:"nan_count"`
+	ErrorCount  int               `json:"error_count"`
+	Mean        number            `json:"mean"`
+	Median      number            `json:"median"`
+	StdDev      number            `json:"stddev"`
+	Min         number            `json:"min"`
+	Max         number            `json:"max"`
+	Percentiles map[string]number `json:"percentiles"`
+}
+
+// WriteJSON writes the whole run, summary and per-instance results, as a single indented JSON document
+func WriteJSON(w io.Writer, run Run) error {
+	document := jsonRun{
+		Name:        run.Name,
+		Description: run.Description,
+		Pairwise:    run.Pairwise,
+		Results:     make([]jsonRow, len(run.Rows)),
+	}
+	if run.Summary != nil {
+		summary := newJSONSummary(*run.Summary)
+		document.Summary = &summary
+	}
+	for i, row := range run.Rows {
+		document.Results[i] = newJSONRow(row, run.Pairwise)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// WriteJSONL writes one JSON object per instance
+func WriteJSONL(w io.Writer, run Run) error {
+	encoder := json.NewEncoder(w)
+	for _, row := range run.Rows {
+		if err := encoder.Encode(newJSONRow(row, run.Pairwise)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSummaryJSON writes a run summary as an indented JSON document
+func WriteSummaryJSON(w io.Writer, summary eval.Summary) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(newJSONSummary(summary))
+}
+
+// ReadJSON reads a run written by WriteJSON. Null scores are read as NaN.
+func ReadJSON(r io.Reader) (Run, error) {
+	var document jsonRun
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return Run{}, err
+	}
+
+	run := Run{
+		Name:        document.Name,
+		Description: document.Description,
+		Pairwise:    document.Pairwise,
+		Rows:        make([]Row, len(document.Results)),
+	}
+	if document.Summary != nil {
+		summary := document.Summary.summary()
+		run.Summary = &summary
+	}
+	for i, encoded := range document.Results {
+		row := Row{
+			ID:         encoded.ID,
+			References: encoded.References,
+			Prediction: encoded.Prediction,
+			Metadata:   encoded.Metadata,
+			Tags:       encoded.Tags,
+			Scores:     make(map[string]float64, len(encoded.Scores)),
+			Errors:     encoded.Errors,
+			Details:    encoded.Details,
+		}
+		if encoded.Reference != nil {
+			row.Reference = *encoded.Reference
+		}
+		for name, score := range encoded.Scores {
+			row.Scores[name] = float64(score)
+		}
+		run.Rows[i] = row
+	}
+	return run, nil
+}
+
+// ReadSummaryJSON reads a run summary written by WriteSummaryJSON, or the summary of a run written by WriteJSON.
+// Null statistics are read as NaN.
+func ReadSummaryJSON(r io.Reader) (eval.Summary, error) {
+	var document struct {
+		jsonSummary
+		Summary *jsonSummary `json:"summary"`
+	}
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return eval.Summary{}, err
+	}
+	if document.Summary != nil {
+		return document.Summary.summary(), nil
+	}
+	if document.Metrics == nil {
+		return eval.Summary{}, errors.New("no summary found")
+	}
+	return document.jsonSummary.summary(), nil
+}
+
+func newJSONRow(row Row, pairwise bool) jsonRow {
+	encoded := jsonRow{
+		ID:         row.ID,
+		References: row.References,
+		Prediction: row.Prediction,
+		Metadata:   row.Metadata,
+		Tags:       row.Tags,
+		Scores:     make(map[string]number, len(row.Scores)),
+		Errors:     row.Errors,
+		Details:    row.Details,
+	}
+	if pairwise {
+		reference := row.Reference
+		encoded.Reference = &reference
+	}
+	for name, score := range row.Scores {
+		encoded.Scores[name] = number(score)
+	}
+	return encoded
+}
+
+func newJSONSummary(summary eval.Summary) jsonSummary {
+	encoded := jsonSummary{
+		Name:        summary.Name,
+		Description: summary.Description,
+		Instances:   summary.Instances,
+		Metrics:     make([]jsonMetricSummary, len(summary.Metrics)),
+	}
+	for i, metric := range summary.Metrics {
+		encoded.Metrics[i] = jsonMetricSummary{
+			Metric:      metric.Metric,
+			Aggregator:  metric.Aggregator,
+			Value:       number(metric.Value),
+			Count:       metric.Count,
+			NaNCount:    metric.NaNCount,
+			ErrorCount:  metric.ErrorCount,
+			Mean:        number(metric.Mean),
+			Median:      number(metric.Median),
+			StdDev:      number(metric.StdDev),
+			Min:         number(metric.Min),
+			Max:         number(metric.Max),
+			Percentiles: make(map[string]number, len(metric.Percentiles)),
+		}
+		for name, value := range metric.Percentiles {
+			encoded.Metrics[i].Percentiles[name] = number(value)
+		}
+	}
+	return encoded
+}
+
+func (s jsonSummary) summary() eval.Summary {
+	summary := eval.Summary{
+		Name:        s.Name,
+		Description: s.Description,
+		Instances:   s.Instances,
+		Metrics:     make([]eval.MetricSummary, len(s.Metrics)),
+	}
+	for i, metric := range s.Metrics {
+		summary.Metrics[i] = eval.MetricSummary{
+			Metric:      metric.Metric,
+			Aggregator:  metric.Aggregator,
+			Value:       float64(metric.Value),
+			Count:       metric.Count,
+			NaNCount:    metric.NaNCount,
+			ErrorCount:  metric.ErrorCount,
+			Mean:        float64(metric.Mean),
+			Median:      float64(metric.Median),
+			StdDev:      float64(metric.StdDev),
+			Min:         float64(metric.Min),
+			Max:         float64(metric.Max),
+			Percentiles: make(map[string]float64, len(metric.Percentiles)),
+		}
+		for name, value := range metric.Percentiles {
+			summary.Metrics[i].Percentiles[name] = float64(value)
+		}
+	}
+	return summary
+}
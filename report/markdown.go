@@ -0,0 +1,91 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// WriteMarkdown writes the run's name and description as a header followed by its summary as a
+// Markdown table, suitable for pasting in pull request comments. Runs without a summary are
+// summarized with the mean of every metric.
+func WriteMarkdown(w io.Writer, run Run) error {
+	summary := run.Summary
+	if summary == nil {
+		computed := eval.SummarizePairwise(run.PairwiseResults(), nil)
+		summary = &computed
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", escapeMarkdown(run.Name))
+	if run.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", escapeMarkdown(run.Description))
+	}
+	writeSummaryTable(&b, *summary)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteSummaryMarkdown writes a run summary as a Markdown table
+func WriteSummaryMarkdown(w io.Writer, summary eval.Summary) error {
+	var b strings.Builder
+	writeSummaryTable(&b, summary)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeSummaryTable(b *strings.Builder, summary eval.Summary) {
+	percentiles := percentileNames(summary)
+
+	header := []string{"Metric", "Value", "Mean", "Median", "Std Dev", "Min", "Max"}
+	header = append(header, percentiles...)
+	header = append(header, "Count", "Errors")
+	writeTableRow(b, header)
+
+	separator := make([]string, len(header))
+	separator[0] = "---"
+	for i := 1; i < len(separator); i++ {
+		separator[i] = "---:"
+	}
+	writeTableRow(b, separator)
+
+	for _, metric := range summary.Metrics {
+		cells := []string{escapeMarkdown(metric.Metric)}
+		if metric.Count == 0 {
+			// Statistics are meaningless without any score
+			for i := 1; i < len(header)-2; i++ {
+				cells = append(cells, "-")
+			}
+		} else {
+			cells = append(cells,
+				formatScore(metric.Value)+" ("+metric.Aggregator+")",
+				formatScore(metric.Mean),
+				formatScore(metric.Median),
+				formatScore(metric.StdDev),
+				formatScore(metric.Min),
+				formatScore(metric.Max),
+			)
+			for _, name := range percentiles {
+				cells = append(cells, formatScore(metric.Percentiles[name]))
+			}
+		}
+		cells = append(cells, strconv.Itoa(metric.Count), strconv.Itoa(metric.ErrorCount))
+		writeTableRow(b, cells)
+	}
+}
+
+func writeTableRow(b *strings.Builder, cells []string) {
+	b.WriteString("| ")
+	b.WriteString(strings.Join(cells, " | "))
+	b.WriteString(" |\n")
+}
+
+// escapeMarkdown escapes the characters that would break a Markdown table cell
+func escapeMarkdown(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.ReplaceAll(text, "\n", " ")
+}
@@ -0,0 +1,225 @@
+// Package report writes evaluation results and summaries in human and machine readable formats
+package report
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// Run holds the results of an evaluation run in a form shared by pairwise and pointwise evaluations
+type Run struct {
+	Name        string
+	Description string
+	// Pairwise is set when the rows compare predictions against references
+	Pairwise bool
+	Rows     []Row
+	// Summary holds the run's summary statistics, it may be nil
+	Summary *eval.Summary
+}
+
+// Row holds the scores of a single instance
+type Row struct {
+	ID         string
+	Reference  string
+	References []string
+	Prediction string
+	Metadata   map[string]string
+	Tags       []string
+	Scores     map[string]float64
+	Errors     map[string]string
+	// Details holds the details attached by metrics explaining their scores, keyed by metric name
+	Details eval.MetricDetails
+}
+
+// NewPairwiseRun creates a run from the results of a pairwise evaluation, summarizing them with the evaluation's aggregators
+func NewPairwiseRun(evaluation *eval.PairwiseEvaluation, results []eval.PairwiseResult) Run {
+	summary := evaluation.Summarize(results)
+	return Run{
+		Name:        evaluation.Name,
+		Description: evaluation.Description,
+		Pairwise:    true,
+		Rows:        PairwiseRows(results),
+		Summary:     &summary,
+	}
+}
+
+// NewPointwiseRun creates a run from the results of a pointwise evaluation, summarizing them with the evaluation's aggregators
+func NewPointwiseRun(evaluation *eval.PointwiseEvaluation, results []eval.PointwiseResult) Run {
+	summary := evaluation.Summarize(results)
+	return Run{
+		Name:        evaluation.Name,
+		Description: evaluation.Description,
+		Rows:        PointwiseRows(results),
+		Summary:     &summary,
+	}
+}
+
+// Aggregators returns the built-in aggregator the run's summary reports for each metric, so that later runs
+// are compared with the aggregators the run was summarized with. Metrics summarized with other aggregators
+// are left out, as are all metrics of a run without a summary.
+func (r Run) Aggregators() map[string]eval.Aggregator {
+	if r.Summary == nil {
+		return nil
+	}
+	aggregators := make(map[string]eval.Aggregator, len(r.Summary.Metrics))
+	for _, metric := range r.Summary.Metrics {
+		if aggregator, ok := eval.LookupAggregator(metric.Aggregator); ok {
+			aggregators[metric.Metric] = aggregator
+		}
+	}
+	return aggregators
+}
+
+// PairwiseRows converts pairwise results into rows
+func PairwiseRows(results []eval.PairwiseResult) []Row {
+	rows := make([]Row, len(results))
+	for i, result := range results {
+		rows[i] = Row{
+			ID:         result.Instance.ID,
+			Reference:  result.Instance.Reference,
+			References: result.Instance.References,
+			Prediction: result.Instance.Prediction,
+			Metadata:   result.Instance.Metadata,
+			Tags:       result.Instance.Tags,
+			Scores:     result.MetricResults,
+			Errors:     errorMessages(result.Errors),
+			Details:    result.Details,
+		}
+	}
+	return rows
+}
+
+// PointwiseRows converts pointwise results into rows
+func PointwiseRows(results []eval.PointwiseResult) []Row {
+	rows := make([]Row, len(results))
+	for i, result := range results {
+		rows[i] = Row{
+			ID:         result.Instance.ID,
+			Prediction: result.Prediction,
+			Metadata:   result.Instance.Metadata,
+			Tags:       result.Instance.Tags,
+			Scores:     result.MetricResults,
+			Errors:     errorMessages(result.Errors),
+			Details:    result.Details,
+		}
+	}
+	return rows
+}
+
+// Metrics returns the metric names of the run in a stable order: the order of the summary
+// followed by any other metric found in the rows, sorted by name
+func (r Run) Metrics() []string {
+	var names []string
+	seen := make(map[string]bool)
+	if r.Summary != nil {
+		for _, metric := range r.Summary.Metrics {
+			if !seen[metric.Metric] {
+				seen[metric.Metric] = true
+				names = append(names, metric.Metric)
+			}
+		}
+	}
+
+	var rest []string
+	for _, row := range r.Rows {
+		for name := range row.Scores {
+			if !seen[name] {
+				seen[name] = true
+				rest = append(rest, name)
+			}
+		}
+		for name := range row.Errors {
+			if !seen[name] {
+				seen[name] = true
+				rest = append(rest, name)
+			}
+		}
+	}
+	sort.Strings(rest)
+
+	return append(names, rest...)
+}
+
+// PairwiseResults converts the rows back into pairwise results, e.g. to compare a stored run with a new one.
+// Metric errors are restored from their messages.
+func (r Run) PairwiseResults() []eval.PairwiseResult {
+	results := make([]eval.PairwiseResult, len(r.Rows))
+	for i, row := range r.Rows {
+		results[i] = eval.PairwiseResult{
+			Instance: eval.Instance{
+				ID:         row.ID,
+				Reference:  row.Reference,
+				References: row.References,
+				Prediction: row.Prediction,
+				Metadata:   row.Metadata,
+				Tags:       row.Tags,
+			},
+			MetricResults: row.Scores,
+			Errors:        metricErrors(row.Errors),
+			Details:       row.Details,
+		}
+	}
+	return results
+}
+
+// PointwiseResults converts the rows back into pointwise results, e.g. to compare a stored run with a new one.
+// Metric errors are restored from their messages.
+func (r Run) PointwiseResults() []eval.PointwiseResult {
+	results := make([]eval.PointwiseResult, len(r.Rows))
+	for i, row := range r.Rows {
+		results[i] = eval.PointwiseResult{
+			Prediction: row.Prediction,
+			Instance: eval.PointwiseInstance{
+				ID:         row.ID,
+				Prediction: row.Prediction,
+				Metadata:   row.Metadata,
+				Tags:       row.Tags,
+			},
+			MetricResults: row.Scores,
+			Errors:        metricErrors(row.Errors),
+			Details:       row.Details,
+		}
+	}
+	return results
+}
+
+func errorMessages(errs eval.MetricErrors) map[string]string {
+	if len(errs) == 0 {
+		return nil
+	}
+	messages := make(map[string]string, len(errs))
+	for name, err := range errs {
+		messages[name] = err.Error()
+	}
+	return messages
+}
+
+func metricErrors(messages map[string]string) eval.MetricErrors {
+	if len(messages) == 0 {
+		return nil
+	}
+	errs := make(eval.MetricErrors, len(messages))
+	for name, message := range messages {
+		errs[name] = errors.New(message)
+	}
+	return errs
+}
+
+// formatScore formats a score with at most four decimals, without trailing zeros
+func formatScore(score float64) string {
+	if math.IsNaN(score) || math.IsInf(score, 0) {
+		return strconv.FormatFloat(score, 'f', -1, 64)
+	}
+	text := strconv.FormatFloat(score, 'f', 4, 64)
+	text = strings.TrimRight(text, "0")
+	text = strings.TrimSuffix(text, ".")
+	if text == "-0" {
+		return "0"
+	}
+	return text
+}
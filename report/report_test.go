@@ -0,0 +1,157 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	eval "github.com/snpu/eval-go"
+)
+
+// testRun returns a pairwise run with a NaN score and a failed metric
+func testRun() Run {
+	results := []eval.PairwiseResult{
+		{
+			Instance:      eval.Instance{ID: "1", Reference: "a cat", Prediction: "the cat", Tags: []string{"en", "qa"}},
+			MetricResults: map[string]float64{"f1": 0.5, "exact": 0},
+		},
+		{
+			Instance:      eval.Instance{ID: "2", Reference: "yes", Prediction: "yes, \"sure\""},
+			MetricResults: map[string]float64{"f1": math.NaN()},
+			Errors:        eval.MetricErrors{"exact": errors.New("boom")},
+		},
+	}
+	summary := eval.SummarizePairwise(results, nil)
+	summary.Name = "qa"
+	summary.Description = "Answers | scored"
+	return Run{
+		Name:        summary.Name,
+		Description: summary.Description,
+		Pairwise:    true,
+		Rows:        PairwiseRows(results),
+		Summary:     &summary,
+	}
+}
+
+func TestRunMetrics(t *testing.T) {
+	run := Run{Rows: []Row{
+		{Scores: map[string]float64{"b": 1, "z": 1}},
+		{Scores: map[string]float64{"a": 1}, Errors: map[string]string{"y": "failed"}},
+	}}
+	run.Summary = &eval.Summary{Metrics: []eval.MetricSummary{{Metric: "z"}, {Metric: "b"}}}
+
+	// Summary order first, then the remaining metrics sorted by name
+	if got, want := strings.Join(run.Metrics(), ","), "z,b,a,y"; got != want {
+		t.Errorf("Metrics() = %s, want %s", got, want)
+	}
+}
+
+func TestRunAggregators(t *testing.T) {
+	results := []eval.PointwiseResult{{MetricResults: map[string]float64{"a": 1, "b": 2, "c": 3}}}
+	custom := eval.Aggregator{Name: "last", Aggregate: func(scores []float64) float64 { return scores[len(scores)-1] }}
+	summary := eval.SummarizePointwise(results, map[string]eval.Aggregator{"a": eval.SumAggregator, "c": custom})
+	run := Run{Rows: PointwiseRows(results), Summary: &summary}
+
+	aggregators := run.Aggregators()
+	if len(aggregators) != 2 || aggregators["a"].Name != "sum" || aggregators["b"].Name != "mean" {
+		t.Errorf("aggregators = %v, want the sum of a and the mean of b", aggregators)
+	}
+	if aggregators := (Run{}).Aggregators(); aggregators != nil {
+		t.Errorf("aggregators of a run without summary = %v", aggregators)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteCSV(&b, testRun()); err != nil {
+		t.Fatal(err)
+	}
+	want := "id,reference,prediction,tags,exact,f1,errors\n" +
+		"1,a cat,the cat,en|qa,0,0.5,\n" +
+		"2,yes,\"yes, \"\"sure\"\"\",,,NaN,exact: boom\n"
+	if b.String() != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWriteSummaryCSV(t *testing.T) {
+	summary := eval.Summary{Metrics: []eval.MetricSummary{{
+		Metric: "f1", Aggregator: "mean", Value: 0.5, Count: 2, Mean: 0.5, Median: 0.5, Min: 0.25, Max: 0.75,
+		Percentiles: map[string]float64{"p90": 0.7, "p5": 0.275},
+	}}}
+	var b bytes.Buffer
+	if err := WriteSummaryCSV(&b, summary); err != nil {
+		t.Fatal(err)
+	}
+	want := "metric,aggregator,value,count,nan_count,error_count,mean,median,stddev,min,max,p5,p90\n" +
+		"f1,mean,0.5,2,0,0,0.5,0.5,0,0.25,0.75,0.275,0.7\n"
+	if b.String() != want {
+		t.Errorf("summary CSV =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	run := testRun()
+	for i := range run.Summary.Metrics {
+		run.Summary.Metrics[i].Percentiles = nil
+	}
+	var b bytes.Buffer
+	if err := WriteMarkdown(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	want := "## qa\n\n" +
+		"Answers \\| scored\n\n" +
+		"| Metric | Value | Mean | Median | Std Dev | Min | Max | Count | Errors |\n" +
+		"| --- | ---: | ---: | ---: | ---: | ---: | ---: | ---: | ---: |\n" +
+		"| exact | 0 (mean) | 0 | 0 | 0 | 0 | 0 | 1 | 1 |\n" +
+		"| f1 | 0.5 (mean) | 0.5 | 0.5 | 0 | 0.5 | 0.5 | 1 | 0 |\n"
+	if b.String() != want {
+		t.Errorf("Markdown =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWriteMarkdownWithoutScores(t *testing.T) {
+	summary := eval.Summary{Metrics: []eval.MetricSummary{{Metric: "m", ErrorCount: 2}}}
+	var b bytes.Buffer
+	if err := WriteSummaryMarkdown(&b, summary); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "| m | - | - | - | - | - | - | 0 | 2 |") {
+		t.Errorf("Markdown =\n%s\nwant placeholders for a metric without scores", b.String())
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteJSONL(&b, testRun()); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"1","reference":"a cat","prediction":"the cat","tags":["en","qa"],"scores":{"exact":0,"f1":0.5}}` + "\n" +
+		`{"id":"2","reference":"yes","prediction":"yes, \"sure\"","scores":{"f1":null},"errors":{"exact":"boom"}}` + "\n"
+	if b.String() != want {
+		t.Errorf("JSONL =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestFormatScore(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{0, "0"},
+		{1, "1"},
+		{0.5, "0.5"},
+		{1.0 / 3, "0.3333"},
+		{0.99999, "1"},
+		{-0.00001, "0"},
+		{math.NaN(), "NaN"},
+		{math.Inf(1), "+Inf"},
+	}
+	for _, tt := range tests {
+		if got := formatScore(tt.score); got != tt.want {
+			t.Errorf("formatScore(%g) = %q, want %q", tt.score, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// shard is a half-open range [start, end) of instance indices computed as a single batch
+type shard struct {
+	start, end int
+}
+
+// splitShards splits n instances into consecutive shards of at most size instances.
+// A size of 0 or less yields a single shard covering every instance.
+func splitShards(n, size int) []shard {
+	if size <= 0 || size >= n {
+		return []shard{{0, n}}
+	}
+
+	shards := make([]shard, 0, (n+size-1)/size)
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		shards = append(shards, shard{start, end})
+	}
+	return shards
+}
+
+// runJobs calls fn for every job index in [0, jobs) using at most concurrency goroutines.
+// The first error cancels the context passed to the remaining jobs and is returned once
+// all running jobs have finished.
+func runJobs(ctx context.Context, concurrency, jobs int, fn func(ctx context.Context, job int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > jobs {
+		concurrency = jobs
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	next := make(chan int)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range next {
+				if err := fn(ctx, job); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for job := 0; job < jobs; job++ {
+		select {
+		case next <- job:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// newColumns allocates the score columns of a metric with the given number of outputs
+func newColumns(outputs, instances int) [][]float64 {
+	columns := make([][]float64, outputs)
+	for c := range columns {
+		columns[c] = make([]float64, instances)
+	}
+	return columns
+}
+
+// copyColumns copies the score columns computed over shard s into the columns of the whole run
+func copyColumns(columns, shardColumns [][]float64, s shard) {
+	for c := range columns {
+		copy(columns[c][s.start:s.end], shardColumns[c])
+	}
+}
+
+// copyDetails copies the details attached to the instances of shard s into the details of the whole run
+func copyDetails(details []Details, recorder *detailsRecorder, s shard) {
+	for i, instanceDetails := range recorder.collected() {
+		if i >= 0 && i < s.end-s.start {
+			details[s.start+i] = instanceDetails
+		}
+	}
+}
+
+// recordFailures attributes a failed metric computation over shard s to its instances.
+// Failures reported as InstanceErrors are recorded against the individual instances and the
+// scores of the remaining ones are kept, any other error is recorded against the whole shard.
+func recordFailures(errs []error, columns, shardColumns [][]float64, s shard, err error) {
+	var instanceErrs InstanceErrors
+	if errors.As(err, &instanceErrs) && checkColumns(shardColumns, len(columns), s.end-s.start) == nil {
+		copyColumns(columns, shardColumns, s)
+		for i, instanceErr := range instanceErrs {
+			if i >= 0 && i < s.end-s.start {
+				errs[s.start+i] = instanceErr
+			}
+		}
+		return
+	}
+
+	for i := s.start; i < s.end; i++ {
+		errs[i] = err
+	}
+}
+
+// mergeScores records the scores of instance i under the result keys of every metric,
+// returning the errors of the metrics that failed on it, recorded under each of their keys
+func mergeScores(metricResults map[string]float64, keys [][]string, scores [][][]float64, errs [][]error, i int) MetricErrors {
+	var metricErrs MetricErrors
+	for m := range keys {
+		if errs != nil && errs[m][i] != nil {
+			if metricErrs == nil {
+				metricErrs = make(MetricErrors)
+			}
+			for _, key := range keys[m] {
+				metricErrs[key] = errs[m][i]
+			}
+			continue
+		}
+		for c, key := range keys[m] {
+			metricResults[key] = scores[m][c][i]
+		}
+	}
+	return metricErrs
+}
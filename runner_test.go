@@ -0,0 +1,145 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// constantPairwise returns a pairwise metric scoring every instance with score
+func constantPairwise(name string, score float64) PairwiseMetric {
+	return NewPairwiseMetric(name, "", func(ctx context.Context, references, predictions []string) ([]float64, error) {
+		scores := make([]float64, len(predictions))
+		for i := range scores {
+			scores[i] = score
+		}
+		return scores, nil
+	})
+}
+
+func TestSplitShards(t *testing.T) {
+	tests := []struct {
+		n, size int
+		want    []shard
+	}{
+		{5, 0, []shard{{0, 5}}},
+		{5, 5, []shard{{0, 5}}},
+		{5, 2, []shard{{0, 2}, {2, 4}, {4, 5}}},
+		{4, 2, []shard{{0, 2}, {2, 4}}},
+	}
+	for _, tt := range tests {
+		if got := splitShards(tt.n, tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitShards(%d, %d) = %v, want %v", tt.n, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestRunJobs(t *testing.T) {
+	var running, peak atomic.Int32
+	var done [20]atomic.Bool
+	err := runJobs(context.Background(), 3, len(done), func(ctx context.Context, job int) error {
+		n := running.Add(1)
+		for {
+			current := peak.Load()
+			if n <= current || peak.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		done[job].Store(true)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak.Load() > 3 {
+		t.Errorf("ran %d jobs at once, want at most 3", peak.Load())
+	}
+	for job := range done {
+		if !done[job].Load() {
+			t.Errorf("job %d did not run", job)
+		}
+	}
+
+	failure := errors.New("job failed")
+	err = runJobs(context.Background(), 2, 100, func(ctx context.Context, job int) error {
+		if job == 0 {
+			return failure
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != failure {
+		t.Errorf("runJobs error = %v, want the first failure", err)
+	}
+}
+
+// batchRecorder returns a pairwise metric scoring the length of each prediction, recording the size of every batch
+func batchRecorder(name string, mu *sync.Mutex, batches *[]int) PairwiseMetric {
+	return NewPairwiseMetric(name, "", func(ctx context.Context, references, predictions []string) ([]float64, error) {
+		mu.Lock()
+		*batches = append(*batches, len(predictions))
+		mu.Unlock()
+		scores := make([]float64, len(predictions))
+		for i, prediction := range predictions {
+			scores[i] = float64(len(prediction))
+		}
+		return scores, nil
+	})
+}
+
+func TestRunConcurrentShards(t *testing.T) {
+	instances := make([]Instance, 10)
+	for i := range instances {
+		instances[i] = Instance{Reference: "r", Prediction: fmt.Sprint(i * 100)}
+	}
+
+	var want []PairwiseResult
+	for _, settings := range []struct{ concurrency, shardSize int }{{1, 0}, {4, 0}, {1, 3}, {4, 3}, {16, 1}} {
+		var mu sync.Mutex
+		var batches []int
+		evaluation := NewPairwiseEvaluation("lengths", "", []PairwiseMetric{
+			batchRecorder("a", &mu, &batches),
+			batchRecorder("b", &mu, &batches),
+		})
+		evaluation.Concurrency, evaluation.ShardSize = settings.concurrency, settings.shardSize
+
+		results, err := evaluation.Run(context.Background(), instances)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			want = results
+		} else if !reflect.DeepEqual(results, want) {
+			t.Errorf("results with %+v differ from sequential results", settings)
+		}
+
+		size := settings.shardSize
+		if size == 0 {
+			size = len(instances)
+		}
+		for _, batch := range batches {
+			if batch > size {
+				t.Errorf("batch of %d predictions with %+v, want at most %d", batch, settings, size)
+			}
+		}
+	}
+	if got := want[3].MetricResults["b"]; got != 3 {
+		t.Errorf("score of instance 3 = %g, want 3", got)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	evaluation := NewPairwiseEvaluation("cancelled", "", []PairwiseMetric{constantPairwise("m", 1)})
+	if _, err := evaluation.Run(ctx, []Instance{{Reference: "a", Prediction: "a"}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,576 @@
+package eval
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// PairedTest identifies a paired significance test between two runs
+type PairedTest string
+
+const (
+	// PairedTTest is Student's paired t-test on the score differences
+	PairedTTest PairedTest = "paired_t"
+	// WilcoxonSignedRankTest is the Wilcoxon signed-rank test on the score differences
+	WilcoxonSignedRankTest PairedTest = "wilcoxon"
+	// PairedPermutationTest is a sign-flip permutation test on the mean score difference
+	PairedPermutationTest PairedTest = "permutation"
+	// SignTest is the exact binomial sign test on the score differences
+	SignTest PairedTest = "sign"
+)
+
+// Correction identifies a multiple comparison correction applied across the metrics of a comparison
+type Correction string
+
+const (
+	// NoCorrection leaves the p-values unadjusted
+	NoCorrection Correction = ""
+	// HolmCorrection applies the Holm-Bonferroni step-down correction, controlling the family-wise error rate
+	HolmCorrection Correction = "holm"
+	// BenjaminiHochbergCorrection applies the Benjamini-Hochberg step-up correction, controlling the false discovery rate
+	BenjaminiHochbergCorrection Correction = "bh"
+)
+
+// CompareOptions configures the paired comparison of two runs
+type CompareOptions struct {
+	// Tests are the paired tests to run on every metric, nil runs all of them
+	Tests []PairedTest
+	// Correction adjusts the p-values of each test for the number of compared metrics
+	Correction Correction
+	// Permutations is the number of random sign flips of the permutation test, 0 means 10000.
+	// Runs of up to 20 pairs are enumerated exactly.
+	Permutations int
+	// Seed makes the permutation test reproducible
+	Seed uint64
+}
+
+// TestResult holds the outcome of a paired test on a single metric
+type TestResult struct {
+	Test PairedTest
+	// Statistic is t for the t-test, the sum of positive ranks for the Wilcoxon test,
+	// the mean difference for the permutation test and the number of positive differences for the sign test
+	Statistic float64
+	// PValue is the two-sided p-value
+	PValue float64
+	// AdjustedPValue is PValue corrected for the number of compared metrics
+	AdjustedPValue float64
+	// EffectSize is Cohen's d_z for the t-test and the permutation test, the matched-pairs
+	// rank-biserial correlation for the Wilcoxon test and (positive - negative) / non-zero
+	// differences for the sign test
+	EffectSize float64
+}
+
+// MetricComparison holds the paired comparison of one metric between two runs.
+// Differences are taken as the second run minus the first.
+type MetricComparison struct {
+	Metric string
+	// Pairs is the number of instances scored in both runs, errored and NaN scores are left out
+	Pairs          int
+	MeanA          float64
+	MeanB          float64
+	MeanDifference float64
+	Tests          []TestResult
+}
+
+// ComparePairwise runs paired significance tests on every metric of two pairwise runs over the same instances.
+// Results are paired by instance ID when every instance has one, by position otherwise.
+func ComparePairwise(a, b []PairwiseResult, opts CompareOptions) ([]MetricComparison, error) {
+	rowsA, rowsB, pairs, err := pairRows(pairwiseRows(a), pairwiseRows(b))
+	if err != nil {
+		return nil, err
+	}
+	for _, pair := range pairs {
+		if a[pair[0]].Instance.Reference != b[pair[1]].Instance.Reference {
+			return nil, fmt.Errorf("result %d has different references in the two runs", pair[0])
+		}
+	}
+	return compare(rowsA, rowsB, opts)
+}
+
+// ComparePointwise runs paired significance tests on every metric of two pointwise runs over the same inputs.
+// Results are paired by instance ID when every instance has one, by position otherwise.
+func ComparePointwise(a, b []PointwiseResult, opts CompareOptions) ([]MetricComparison, error) {
+	rowsA, rowsB, _, err := pairRows(pointwiseRows(a), pointwiseRows(b))
+	if err != nil {
+		return nil, err
+	}
+	return compare(rowsA, rowsB, opts)
+}
+
+// pairRows aligns the rows of two runs, returning the paired rows along with the indices of each pair.
+// Rows are matched by ID when every row of both runs has one, in which case rows without a
+// counterpart are left out, and by position otherwise.
+func pairRows(a, b []row) ([]row, []row, [][2]int, error) {
+	if !hasIDs(a) || !hasIDs(b) {
+		if len(a) != len(b) {
+			return nil, nil, nil, fmt.Errorf("number of results (%d) does not match number of results (%d)", len(a), len(b))
+		}
+		pairs := make([][2]int, len(a))
+		for i := range pairs {
+			pairs[i] = [2]int{i, i}
+		}
+		return a, b, pairs, nil
+	}
+
+	indexB := make(map[string]int, len(b))
+	for i, r := range b {
+		if _, ok := indexB[r.id]; ok {
+			return nil, nil, nil, fmt.Errorf("duplicate instance ID %q", r.id)
+		}
+		indexB[r.id] = i
+	}
+
+	var pairedA, pairedB []row
+	var pairs [][2]int
+	seen := make(map[string]bool, len(a))
+	for i, r := range a {
+		if seen[r.id] {
+			return nil, nil, nil, fmt.Errorf("duplicate instance ID %q", r.id)
+		}
+		seen[r.id] = true
+
+		j, ok := indexB[r.id]
+		if !ok {
+			continue
+		}
+		pairedA = append(pairedA, r)
+		pairedB = append(pairedB, b[j])
+		pairs = append(pairs, [2]int{i, j})
+	}
+	return pairedA, pairedB, pairs, nil
+}
+
+// hasIDs reports whether every row has an instance ID
+func hasIDs(rows []row) bool {
+	for _, r := range rows {
+		if r.id == "" {
+			return false
+		}
+	}
+	return len(rows) > 0
+}
+
+func compare(a, b []row, opts CompareOptions) ([]MetricComparison, error) {
+	if opts.Tests == nil {
+		opts.Tests = []PairedTest{PairedTTest, WilcoxonSignedRankTest, PairedPermutationTest, SignTest}
+	}
+	if opts.Permutations == 0 {
+		opts.Permutations = 10000
+	}
+	if opts.Permutations < 0 {
+		return nil, fmt.Errorf("invalid number of permutations %d", opts.Permutations)
+	}
+	for _, test := range opts.Tests {
+		switch test {
+		case PairedTTest, WilcoxonSignedRankTest, PairedPermutationTest, SignTest:
+		default:
+			return nil, fmt.Errorf("unknown paired test %q", test)
+		}
+	}
+	switch opts.Correction {
+	case NoCorrection, HolmCorrection, BenjaminiHochbergCorrection:
+	default:
+		return nil, fmt.Errorf("unknown correction %q", opts.Correction)
+	}
+
+	// Only metrics present in both runs can be compared
+	inB := make(map[string]bool)
+	for _, name := range metricNames(b, nil) {
+		inB[name] = true
+	}
+
+	var comparisons []MetricComparison
+	for _, name := range metricNames(a, nil) {
+		if !inB[name] {
+			continue
+		}
+
+		var scoresA, scoresB []float64
+		for i := range a {
+			scoreA, okA := pairedScore(a[i], name)
+			scoreB, okB := pairedScore(b[i], name)
+			if okA && okB {
+				scoresA = append(scoresA, scoreA)
+				scoresB = append(scoresB, scoreB)
+			}
+		}
+
+		diffs := make([]float64, len(scoresA))
+		for i := range diffs {
+			diffs[i] = scoresB[i] - scoresA[i]
+		}
+
+		comparison := MetricComparison{
+			Metric:         name,
+			Pairs:          len(diffs),
+			MeanA:          mean(scoresA),
+			MeanB:          mean(scoresB),
+			MeanDifference: mean(diffs),
+		}
+		for _, test := range opts.Tests {
+			var result TestResult
+			switch test {
+			case PairedTTest:
+				result = pairedTTest(diffs)
+			case WilcoxonSignedRankTest:
+				result = wilcoxonSignedRank(diffs)
+			case PairedPermutationTest:
+				hash := fnv.New64a()
+				hash.Write([]byte(name))
+				result = pairedPermutation(diffs, opts.Permutations, rand.New(rand.NewPCG(opts.Seed, hash.Sum64())))
+			case SignTest:
+				result = signTest(diffs)
+			}
+			result.AdjustedPValue = result.PValue
+			comparison.Tests = append(comparison.Tests, result)
+		}
+		comparisons = append(comparisons, comparison)
+	}
+
+	// Correct every test separately across the compared metrics
+	for t := range opts.Tests {
+		pValues := make([]float64, len(comparisons))
+		for m := range comparisons {
+			pValues[m] = comparisons[m].Tests[t].PValue
+		}
+		adjusted := adjustPValues(pValues, opts.Correction)
+		for m := range comparisons {
+			comparisons[m].Tests[t].AdjustedPValue = adjusted[m]
+		}
+	}
+
+	return comparisons, nil
+}
+
+// pairedScore returns the score of a metric in r unless it errored or is NaN
+func pairedScore(r row, name string) (float64, bool) {
+	if _, failed := r.errors[name]; failed {
+		return 0, false
+	}
+	score, ok := r.scores[name]
+	if !ok || math.IsNaN(score) {
+		return 0, false
+	}
+	return score, true
+}
+
+// adjustPValues corrects p-values for multiple comparisons, keeping their order
+func adjustPValues(pValues []float64, correction Correction) []float64 {
+	adjusted := append([]float64(nil), pValues...)
+	if correction == NoCorrection || len(pValues) == 0 {
+		return adjusted
+	}
+
+	order := make([]int, len(pValues))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return pValues[order[i]] < pValues[order[j]] })
+
+	m := float64(len(pValues))
+	switch correction {
+	case HolmCorrection:
+		running := 0.0
+		for rank, i := range order {
+			running = math.Max(running, math.Min(1, (m-float64(rank))*pValues[i]))
+			adjusted[i] = running
+		}
+	case BenjaminiHochbergCorrection:
+		running := 1.0
+		for rank := len(order) - 1; rank >= 0; rank-- {
+			i := order[rank]
+			running = math.Min(running, math.Min(1, m/float64(rank+1)*pValues[i]))
+			adjusted[i] = running
+		}
+	}
+	return adjusted
+}
+
+// cohensDz returns the standardized mean difference of paired differences
+func cohensDz(diffs []float64) float64 {
+	m, sd := mean(diffs), stdDev(diffs)
+	if sd == 0 {
+		if m == 0 {
+			return 0
+		}
+		return math.Copysign(math.Inf(1), m)
+	}
+	return m / sd
+}
+
+func pairedTTest(diffs []float64) TestResult {
+	result := TestResult{Test: PairedTTest, PValue: 1}
+	n := len(diffs)
+	if n < 2 {
+		return result
+	}
+
+	m, sd := mean(diffs), stdDev(diffs)
+	result.EffectSize = cohensDz(diffs)
+	if sd == 0 {
+		if m != 0 {
+			result.Statistic = math.Copysign(math.Inf(1), m)
+			result.PValue = 0
+		}
+		return result
+	}
+
+	t := m / (sd / math.Sqrt(float64(n)))
+	df := float64(n - 1)
+	result.Statistic = t
+	result.PValue = regularizedIncompleteBeta(df/(df+t*t), df/2, 0.5)
+	return result
+}
+
+// wilcoxonSignedRank drops zero differences and uses the exact null distribution for up to 50
+// untied differences, the normal approximation with tie and continuity correction otherwise
+func wilcoxonSignedRank(diffs []float64) TestResult {
+	result := TestResult{Test: WilcoxonSignedRankTest, PValue: 1}
+
+	nonZero := make([]float64, 0, len(diffs))
+	for _, d := range diffs {
+		if d != 0 {
+			nonZero = append(nonZero, d)
+		}
+	}
+	n := len(nonZero)
+	if n == 0 {
+		return result
+	}
+
+	sort.Slice(nonZero, func(i, j int) bool { return math.Abs(nonZero[i]) < math.Abs(nonZero[j]) })
+
+	// Average ranks over ties of absolute differences
+	ranks := make([]float64, n)
+	tieCorrection := 0.0
+	ties := false
+	for i := 0; i < n; {
+		j := i
+		for j < n && math.Abs(nonZero[j]) == math.Abs(nonZero[i]) {
+			j++
+		}
+		for k := i; k < j; k++ {
+			ranks[k] = float64(i+j+1) / 2
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieCorrection += t*t*t - t
+		}
+		i = j
+	}
+
+	positive, negative := 0.0, 0.0
+	for i, d := range nonZero {
+		if d > 0 {
+			positive += ranks[i]
+		} else {
+			negative += ranks[i]
+		}
+	}
+	result.Statistic = positive
+	result.EffectSize = (positive - negative) / (positive + negative)
+
+	if !ties && n <= 50 {
+		result.PValue = wilcoxonExactPValue(n, positive)
+		return result
+	}
+
+	nf := float64(n)
+	expected := nf * (nf + 1) / 4
+	variance := nf*(nf+1)*(2*nf+1)/24 - tieCorrection/48
+	if variance <= 0 {
+		return result
+	}
+	z := math.Max(math.Abs(positive-expected)-0.5, 0) / math.Sqrt(variance)
+	result.PValue = math.Min(1, math.Erfc(z/math.Sqrt2))
+	return result
+}
+
+// wilcoxonExactPValue returns the two-sided p-value of the signed-rank statistic w over n untied ranks
+func wilcoxonExactPValue(n int, w float64) float64 {
+	maxSum := n * (n + 1) / 2
+
+	// counts[s] is the number of subsets of ranks 1..n summing to s
+	counts := make([]float64, maxSum+1)
+	counts[0] = 1
+	for r := 1; r <= n; r++ {
+		for s := maxSum; s >= r; s-- {
+			counts[s] += counts[s-r]
+		}
+	}
+
+	total := math.Pow(2, float64(n))
+	lower := int(math.Min(w, float64(maxSum)-w))
+	tail := 0.0
+	for s := 0; s <= lower; s++ {
+		tail += counts[s]
+	}
+	return math.Min(1, 2*tail/total)
+}
+
+// pairedPermutation flips the signs of the differences, exhaustively for up to 20 pairs and
+// with the given number of random flips otherwise
+func pairedPermutation(diffs []float64, permutations int, rng *rand.Rand) TestResult {
+	result := TestResult{Test: PairedPermutationTest, PValue: 1}
+	n := len(diffs)
+	if n == 0 {
+		return result
+	}
+
+	observed := mean(diffs)
+	result.Statistic = observed
+	result.EffectSize = cohensDz(diffs)
+
+	// Compare sums rather than means, with a tolerance for rounding
+	threshold := math.Abs(observed*float64(n)) - 1e-9*math.Max(1, math.Abs(observed*float64(n)))
+
+	if n <= 20 {
+		extreme := 0
+		for mask := 0; mask < 1<<n; mask++ {
+			total := 0.0
+			for i, d := range diffs {
+				if mask&(1<<i) != 0 {
+					total -= d
+				} else {
+					total += d
+				}
+			}
+			if math.Abs(total) >= threshold {
+				extreme++
+			}
+		}
+		result.PValue = float64(extreme) / float64(int(1)<<n)
+		return result
+	}
+
+	extreme := 0
+	for p := 0; p < permutations; p++ {
+		total := 0.0
+		for _, d := range diffs {
+			if rng.IntN(2) == 0 {
+				total -= d
+			} else {
+				total += d
+			}
+		}
+		if math.Abs(total) >= threshold {
+			extreme++
+		}
+	}
+	result.PValue = float64(extreme+1) / float64(permutations+1)
+	return result
+}
+
+func signTest(diffs []float64) TestResult {
+	result := TestResult{Test: SignTest, PValue: 1}
+
+	positive, negative := 0, 0
+	for _, d := range diffs {
+		if d > 0 {
+			positive++
+		} else if d < 0 {
+			negative++
+		}
+	}
+	n := positive + negative
+	result.Statistic = float64(positive)
+	if n == 0 {
+		return result
+	}
+	result.EffectSize = float64(positive-negative) / float64(n)
+
+	// Two-sided exact binomial p-value with success probability one half
+	k := positive
+	if negative < k {
+		k = negative
+	}
+	tail := 0.0
+	for i := 0; i <= k; i++ {
+		tail += math.Exp(logChoose(n, i) - float64(n)*math.Ln2)
+	}
+	result.PValue = math.Min(1, 2*tail)
+	return result
+}
+
+func logChoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+// regularizedIncompleteBeta returns I_x(a, b) using the continued fraction expansion
+func regularizedIncompleteBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+
+	// The continued fraction converges quickly for x < (a+1)/(a+b+2), use the symmetry otherwise
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+// betaContinuedFraction evaluates the continued fraction of the incomplete beta function with Lentz's method
+func betaContinuedFraction(x, a, b float64) float64 {
+	const (
+		maxIterations = 300
+		epsilon       = 1e-15
+		tiny          = 1e-300
+	)
+
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+
+	for m := 1; m <= maxIterations; m++ {
+		mf := float64(m)
+
+		// Even step
+		numerator := mf * (b - mf) * x / ((a + 2*mf - 1) * (a + 2*mf))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+
+		// Odd step
+		numerator = -(a + mf) * (a + b + mf) * x / ((a + 2*mf) * (a + 2*mf + 1))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
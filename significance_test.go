@@ -0,0 +1,177 @@
+package eval
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPairedTTest(t *testing.T) {
+	tests := []struct {
+		name      string
+		diffs     []float64
+		statistic float64
+		pValue    float64
+	}{
+		// With 2 and 4 degrees of freedom, the t distribution has closed-form tails
+		{"two degrees of freedom", []float64{1, 2, 3}, 2 * math.Sqrt(3), 1 - 2*math.Sqrt(3)/math.Sqrt(14)},
+		{"four degrees of freedom", []float64{0.1, 0.3, -0.1, 0.4, 0.2}, 2.0924574973887475, 0.10453999977837536},
+		{"constant differences", []float64{0.5, 0.5, 0.5}, math.Inf(1), 0},
+		{"no difference", []float64{0, 0, 0}, 0, 1},
+		{"single pair", []float64{1}, 0, 1},
+	}
+	for _, tt := range tests {
+		result := pairedTTest(tt.diffs)
+		if !closeTo(result.Statistic, tt.statistic) && result.Statistic != tt.statistic {
+			t.Errorf("%s: t = %g, want %g", tt.name, result.Statistic, tt.statistic)
+		}
+		if !closeTo(result.PValue, tt.pValue) {
+			t.Errorf("%s: p = %.12g, want %.12g", tt.name, result.PValue, tt.pValue)
+		}
+	}
+
+	if result := pairedTTest([]float64{0.1, 0.3, -0.1, 0.4, 0.2}); !closeTo(result.EffectSize, 0.9357754408380655) {
+		t.Errorf("d_z = %g, want 0.9358", result.EffectSize)
+	}
+}
+
+func TestWilcoxonSignedRank(t *testing.T) {
+	tests := []struct {
+		name      string
+		diffs     []float64
+		statistic float64
+		pValue    float64
+	}{
+		// Exact: 2 of the 32 sign assignments of ranks 1 to 5 have a positive rank sum of 14 or more
+		{"exact", []float64{-1, 2, 3, 4, 5}, 14, 0.125},
+		{"exact with zeros dropped", []float64{0, 1, 2, 0, 3, 4, 5}, 15, 0.0625},
+		// Ties use the normal approximation with tie and continuity correction: z = 8 / sqrt(21.75)
+		{"ties", []float64{1, 1, -1, 2, 2, 2}, 19, 0.0862755695825295},
+		{"no difference", []float64{0, 0}, 0, 1},
+	}
+	for _, tt := range tests {
+		result := wilcoxonSignedRank(tt.diffs)
+		if result.Statistic != tt.statistic {
+			t.Errorf("%s: W+ = %g, want %g", tt.name, result.Statistic, tt.statistic)
+		}
+		if !closeTo(result.PValue, tt.pValue) {
+			t.Errorf("%s: p = %.12g, want %.12g", tt.name, result.PValue, tt.pValue)
+		}
+	}
+
+	if result := wilcoxonSignedRank([]float64{-1, 2, 3, 4, 5}); !closeTo(result.EffectSize, 13.0/15) {
+		t.Errorf("rank-biserial correlation = %g, want 13/15", result.EffectSize)
+	}
+}
+
+func TestSignTest(t *testing.T) {
+	// 8 positive differences out of 10: p = 2 * (1 + 10 + 45) / 1024
+	diffs := []float64{1, 1, 1, 1, 1, 1, 1, 1, -1, -1, 0}
+	result := signTest(diffs)
+	if result.Statistic != 8 || !closeTo(result.PValue, 0.109375) || !closeTo(result.EffectSize, 0.6) {
+		t.Errorf("signTest = %+v, want statistic 8, p 0.109375 and effect size 0.6", result)
+	}
+	if result := signTest([]float64{0}); result.PValue != 1 {
+		t.Errorf("p without differences = %g, want 1", result.PValue)
+	}
+}
+
+func TestPairedPermutation(t *testing.T) {
+	// Only the identity and the flip of every sign reach a sum of 6
+	if result := pairedPermutation([]float64{1, 2, 3}, 0, nil); !closeTo(result.PValue, 0.25) {
+		t.Errorf("exact p = %g, want 0.25", result.PValue)
+	}
+
+	diffs := make([]float64, 30)
+	for i := range diffs {
+		diffs[i] = float64(i%7) - 2
+	}
+	compare := func() float64 {
+		comparisons, err := ComparePointwise(
+			pointwiseScores("m", make([]float64, len(diffs))), pointwiseScores("m", diffs),
+			CompareOptions{Tests: []PairedTest{PairedPermutationTest}, Permutations: 2000, Seed: 7})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return comparisons[0].Tests[0].PValue
+	}
+	first, second := compare(), compare()
+	if first != second {
+		t.Errorf("p-values with the same seed differ: %g and %g", first, second)
+	}
+	if first <= 0 || first > 1 {
+		t.Errorf("sampled p = %g, want in (0, 1]", first)
+	}
+}
+
+func TestAdjustPValues(t *testing.T) {
+	pValues := []float64{0.01, 0.04, 0.03}
+	tests := []struct {
+		correction Correction
+		want       []float64
+	}{
+		{NoCorrection, []float64{0.01, 0.04, 0.03}},
+		{HolmCorrection, []float64{0.03, 0.06, 0.06}},
+		{BenjaminiHochbergCorrection, []float64{0.03, 0.04, 0.04}},
+	}
+	for _, tt := range tests {
+		if got := adjustPValues(pValues, tt.correction); !equalScores(got, tt.want) {
+			t.Errorf("adjustPValues with %q = %v, want %v", tt.correction, got, tt.want)
+		}
+	}
+}
+
+func TestComparePairwise(t *testing.T) {
+	a := []PairwiseResult{
+		{Instance: Instance{ID: "x"}, MetricResults: map[string]float64{"m": 0.1, "only_a": 1}},
+		{Instance: Instance{ID: "y"}, MetricResults: map[string]float64{"m": 0.2}},
+		{Instance: Instance{ID: "z"}, MetricResults: map[string]float64{"m": 0.3}},
+		{Instance: Instance{ID: "w"}, MetricResults: map[string]float64{"m": 0.4}},
+	}
+	// Paired by ID: w is missing from b, and the errored score of z is left out
+	b := []PairwiseResult{
+		{Instance: Instance{ID: "y"}, MetricResults: map[string]float64{"m": 0.5}},
+		{Instance: Instance{ID: "z"}, MetricResults: map[string]float64{"m": 0.9}, Errors: MetricErrors{"m": errTest}},
+		{Instance: Instance{ID: "x"}, MetricResults: map[string]float64{"m": 0.2}},
+	}
+
+	comparisons, err := ComparePairwise(a, b, CompareOptions{Tests: []PairedTest{SignTest}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comparisons) != 1 {
+		t.Fatalf("compared %d metrics, want only m", len(comparisons))
+	}
+	comparison := comparisons[0]
+	if comparison.Pairs != 2 || !closeTo(comparison.MeanA, 0.15) || !closeTo(comparison.MeanB, 0.35) || !closeTo(comparison.MeanDifference, 0.2) {
+		t.Errorf("comparison = %+v, want 2 pairs with means 0.15 and 0.35", comparison)
+	}
+
+	for _, opts := range []CompareOptions{
+		{Tests: []PairedTest{"z_test"}},
+		{Correction: "bonferroni"},
+		{Permutations: -1},
+	} {
+		if _, err := ComparePairwise(a, b, opts); err == nil {
+			t.Errorf("ComparePairwise with %+v succeeded, want an error", opts)
+		}
+	}
+	if _, err := ComparePairwise(a[:1], b[:2], CompareOptions{}); err != nil {
+		t.Errorf("ComparePairwise of runs paired by ID failed: %v", err)
+	}
+	a[0].Instance.ID = ""
+	if _, err := ComparePairwise(a, b, CompareOptions{}); err == nil {
+		t.Error("ComparePairwise of runs of different lengths paired by position succeeded, want an error")
+	}
+}
+
+func equalScores(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !closeTo(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
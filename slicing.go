@@ -0,0 +1,171 @@
+package eval
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// sliceSeparator joins the slice names of crossed slice functions
+const sliceSeparator = " × "
+
+// SliceFunc returns the names of the slices an instance belongs to, given its tags and metadata.
+// An instance may belong to several slices or to none.
+type SliceFunc func(tags []string, metadata map[string]string) []string
+
+// SliceSummary holds the summary statistics of the instances belonging to one slice
+type SliceSummary struct {
+	Slice   string
+	Count   int
+	Summary Summary
+}
+
+// ByTag slices instances by each of their tags
+func ByTag() SliceFunc {
+	return func(tags []string, metadata map[string]string) []string {
+		return tags
+	}
+}
+
+// ByTagPrefix slices instances by each of their tags starting with prefix, e.g. "lang:" for tags like "lang:en"
+func ByTagPrefix(prefix string) SliceFunc {
+	return func(tags []string, metadata map[string]string) []string {
+		var slices []string
+		for _, tag := range tags {
+			if strings.HasPrefix(tag, prefix) {
+				slices = append(slices, tag)
+			}
+		}
+		return slices
+	}
+}
+
+// ByMetadata slices instances by the value of a metadata key, naming slices "key=value".
+// Instances without the key are left out.
+func ByMetadata(key string) SliceFunc {
+	return func(tags []string, metadata map[string]string) []string {
+		value, ok := metadata[key]
+		if !ok {
+			return nil
+		}
+		return []string{key + "=" + value}
+	}
+}
+
+// CrossSlices slices instances by every combination of the slices of the given functions,
+// e.g. crossing languages with tasks yields slices like "lang:en × task:qa"
+func CrossSlices(funcs ...SliceFunc) SliceFunc {
+	return func(tags []string, metadata map[string]string) []string {
+		combined := []string{""}
+		for i, fn := range funcs {
+			slices := fn(tags, metadata)
+			next := make([]string, 0, len(combined)*len(slices))
+			for _, prefix := range combined {
+				for _, slice := range slices {
+					if i == 0 {
+						next = append(next, slice)
+					} else {
+						next = append(next, prefix+sliceSeparator+slice)
+					}
+				}
+			}
+			combined = next
+		}
+		if len(funcs) == 0 {
+			return nil
+		}
+		return combined
+	}
+}
+
+// Slice summarizes the results of this evaluation separately for every slice, sorted by slice name
+func (e *PairwiseEvaluation) Slice(results []PairwiseResult, by SliceFunc) []SliceSummary {
+	order := e.resultKeys()
+
+	slices := slice(pairwiseRows(results), by, order, e.Aggregators())
+	for i := range slices {
+		slices[i].Summary.Name = e.Name
+		slices[i].Summary.Description = e.Description
+	}
+	return slices
+}
+
+// Slice summarizes the results of this evaluation separately for every slice, sorted by slice name
+func (e *PointwiseEvaluation) Slice(results []PointwiseResult, by SliceFunc) []SliceSummary {
+	order := e.resultKeys()
+
+	slices := slice(pointwiseRows(results), by, order, e.Aggregators())
+	for i := range slices {
+		slices[i].Summary.Name = e.Name
+		slices[i].Summary.Description = e.Description
+	}
+	return slices
+}
+
+// SlicePairwise summarizes pairwise results separately for every slice, sorted by slice name.
+// Metrics missing from aggregators are aggregated with MeanAggregator.
+func SlicePairwise(results []PairwiseResult, by SliceFunc, aggregators map[string]Aggregator) []SliceSummary {
+	return slice(pairwiseRows(results), by, nil, aggregators)
+}
+
+// SlicePointwise summarizes pointwise results separately for every slice, sorted by slice name.
+// Metrics missing from aggregators are aggregated with MeanAggregator.
+func SlicePointwise(results []PointwiseResult, by SliceFunc, aggregators map[string]Aggregator) []SliceSummary {
+	return slice(pointwiseRows(results), by, nil, aggregators)
+}
+
+func slice(rows []row, by SliceFunc, order []string, aggregators map[string]Aggregator) []SliceSummary {
+	groups := make(map[string][]row)
+	for _, r := range rows {
+		// An instance listing the same slice twice is only counted once
+		seen := make(map[string]bool)
+		for _, name := range by(r.tags, r.metadata) {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			groups[name] = append(groups[name], r)
+		}
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	slices := make([]SliceSummary, len(names))
+	for i, name := range names {
+		slices[i] = SliceSummary{
+			Slice:   name,
+			Count:   len(groups[name]),
+			Summary: summarize(groups[name], order, aggregators),
+		}
+	}
+	return slices
+}
+
+// WorstSlice returns the slice with the worst aggregate value of a metric among the slices with at
+// least minCount instances. The worst value is the lowest one when higherIsBetter and the highest otherwise.
+func WorstSlice(slices []SliceSummary, metric string, higherIsBetter bool, minCount int) (SliceSummary, bool) {
+	var worst SliceSummary
+	found := false
+	worstValue := math.Inf(1)
+	if !higherIsBetter {
+		worstValue = math.Inf(-1)
+	}
+
+	for _, s := range slices {
+		if s.Count < minCount {
+			continue
+		}
+		summary, ok := s.Summary.Metric(metric)
+		if !ok || summary.Count == 0 {
+			continue
+		}
+		if !found || (higherIsBetter && summary.Value < worstValue) || (!higherIsBetter && summary.Value > worstValue) {
+			worst, worstValue, found = s, summary.Value, true
+		}
+	}
+	return worst, found
+}
@@ -0,0 +1,120 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestSliceFuncs(t *testing.T) {
+	tags := []string{"lang:en", "task:qa", "lang:fr", "hard"}
+	metadata := map[string]string{"source": "web"}
+	tests := []struct {
+		name string
+		by   SliceFunc
+		want []string
+	}{
+		{"tag", ByTag(), tags},
+		{"tag prefix", ByTagPrefix("lang:"), []string{"lang:en", "lang:fr"}},
+		{"missing prefix", ByTagPrefix("topic:"), nil},
+		{"metadata", ByMetadata("source"), []string{"source=web"}},
+		{"missing metadata", ByMetadata("split"), nil},
+		{"cross", CrossSlices(ByTagPrefix("lang:"), ByTagPrefix("task:")), []string{"lang:en × task:qa", "lang:fr × task:qa"}},
+		{"cross with missing", CrossSlices(ByTagPrefix("lang:"), ByMetadata("split")), []string{}},
+		{"cross nothing", CrossSlices(), nil},
+	}
+	for _, tt := range tests {
+		got := tt.by(tags, metadata)
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("%s: slices = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSlicePairwise(t *testing.T) {
+	results := []PairwiseResult{
+		{Instance: Instance{Tags: []string{"en", "qa"}}, MetricResults: map[string]float64{"m": 1}},
+		{Instance: Instance{Tags: []string{"en", "en"}}, MetricResults: map[string]float64{"m": 0}},
+		{Instance: Instance{Tags: []string{"fr"}}, MetricResults: map[string]float64{"m": 0.5}},
+		{Instance: Instance{}, MetricResults: map[string]float64{"m": 0}},
+	}
+
+	slices := SlicePairwise(results, ByTag(), map[string]Aggregator{"m": SumAggregator})
+	want := []struct {
+		slice string
+		count int
+		value float64
+	}{
+		// An instance tagged twice is counted once, untagged instances belong to no slice
+		{"en", 2, 1},
+		{"fr", 1, 0.5},
+		{"qa", 1, 1},
+	}
+	if len(slices) != len(want) {
+		t.Fatalf("got %d slices, want %d: %+v", len(slices), len(want), slices)
+	}
+	for i, w := range want {
+		s := slices[i]
+		summary, ok := s.Summary.Metric("m")
+		if s.Slice != w.slice || s.Count != w.count || !ok || summary.Value != w.value || summary.Aggregator != "sum" {
+			t.Errorf("slice %d = %q with %d instances and %+v, want %q with %d and sum %g", i, s.Slice, s.Count, summary, w.slice, w.count, w.value)
+		}
+	}
+}
+
+func TestEvaluationSlice(t *testing.T) {
+	evaluation := NewPairwiseEvaluation("sliced", "", []PairwiseMetric{constantPairwise("m", 1)})
+	results, err := evaluation.Run(context.Background(), []Instance{
+		{Prediction: "x", Metadata: map[string]string{"lang": "en"}},
+		{Prediction: "y", Metadata: map[string]string{"lang": "de"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices := evaluation.Slice(results, ByMetadata("lang"))
+	if len(slices) != 2 || slices[0].Slice != "lang=de" || slices[1].Slice != "lang=en" {
+		t.Fatalf("slices = %+v, want lang=de and lang=en", slices)
+	}
+	if slices[0].Summary.Name != "sliced" || slices[0].Count != 1 {
+		t.Errorf("slice summary = %+v", slices[0])
+	}
+}
+
+func TestWorstSlice(t *testing.T) {
+	summary := func(slice string, count int, value float64) SliceSummary {
+		return SliceSummary{Slice: slice, Count: count, Summary: Summary{Metrics: []MetricSummary{
+			{Metric: "m", Value: value, Count: count},
+		}}}
+	}
+	slices := []SliceSummary{
+		summary("a", 10, 0.8),
+		summary("b", 2, 0.1),
+		summary("c", 5, 0.4),
+		summary("d", 8, 0.9),
+		{Slice: "empty", Count: 10},
+	}
+
+	tests := []struct {
+		higherIsBetter bool
+		minCount       int
+		want           string
+	}{
+		{true, 0, "b"},
+		{true, 3, "c"},
+		{false, 0, "d"},
+		{false, 9, "a"},
+	}
+	for _, tt := range tests {
+		worst, ok := WorstSlice(slices, "m", tt.higherIsBetter, tt.minCount)
+		if !ok || worst.Slice != tt.want {
+			t.Errorf("WorstSlice(higherIsBetter=%v, minCount=%d) = %q, %v, want %q", tt.higherIsBetter, tt.minCount, worst.Slice, ok, tt.want)
+		}
+	}
+	if _, ok := WorstSlice(slices, "m", true, 11); ok {
+		t.Error("WorstSlice found a slice with too few instances")
+	}
+	if _, ok := WorstSlice(slices, "missing", true, 0); ok {
+		t.Error("WorstSlice found a slice for a missing metric")
+	}
+}
@@ -0,0 +1,120 @@
+// Package store saves evaluation runs to disk and loads them back, e.g. to compare new runs against a baseline
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snpu/eval-go/report"
+)
+
+// extension is the file extension of stored runs
+const extension = ".json"
+
+// ErrNotFound is returned when loading a run that was never saved
+var ErrNotFound = errors.New("run not found")
+
+// Store keeps runs as JSON files named after them in a directory, in the format of report.WriteJSON
+type Store struct {
+	Dir string
+}
+
+// New creates a store keeping its runs in dir, the directory is created by the first save
+func New(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// Save writes a run under name, replacing any run saved under the same name.
+// The file is written to a temporary file first so readers never see a partial run.
+func (s *Store) Save(name string, run report.Run) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(s.Dir, "."+name+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := report.WriteJSON(file, run); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// Load reads the run saved under name, returning an error wrapping ErrNotFound when there is none
+func (s *Store) Load(name string) (report.Run, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return report.Run{}, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return report.Run{}, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return report.Run{}, err
+	}
+	defer file.Close()
+
+	run, err := report.ReadJSON(file)
+	if err != nil {
+		return report.Run{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return run, nil
+}
+
+// Delete removes the run saved under name, deleting a run that does not exist is not an error
+func (s *Store) Delete(name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List returns the names of the saved runs, sorted
+func (s *Store) List() ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasSuffix(name, extension) && !strings.HasPrefix(name, ".") {
+			names = append(names, strings.TrimSuffix(name, extension))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// path returns the file of the run saved under name, rejecting names that would escape the directory
+func (s *Store) path(name string) (string, error) {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid run name %q", name)
+	}
+	return filepath.Join(s.Dir, name+extension), nil
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	eval "github.com/snpu/eval-go"
+	"github.com/snpu/eval-go/report"
+)
+
+func TestSaveLoad(t *testing.T) {
+	results := []eval.PairwiseResult{
+		{
+			Instance:      eval.Instance{ID: "1", Reference: "a", References: []string{"b"}, Prediction: "c", Tags: []string{"en"}, Metadata: map[string]string{"k": "v"}},
+			MetricResults: map[string]float64{"m": 0.25},
+		},
+		{
+			Instance:      eval.Instance{ID: "2", Prediction: "d"},
+			MetricResults: map[string]float64{"m": math.NaN()},
+			Errors:        eval.MetricErrors{"n": errors.New("boom")},
+		},
+	}
+	summary := eval.SummarizePairwise(results, map[string]eval.Aggregator{"m": eval.SumAggregator})
+	run := report.Run{Name: "run", Description: "desc", Pairwise: true, Rows: report.PairwiseRows(results), Summary: &summary}
+
+	store := New(filepath.Join(t.TempDir(), "runs"))
+	if err := store.Save("baseline", run); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := store.Load("baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NaN scores survive as null, compare them apart
+	if !math.IsNaN(loaded.Rows[1].Scores["m"]) {
+		t.Errorf("NaN score read back as %g", loaded.Rows[1].Scores["m"])
+	}
+	loaded.Rows[1].Scores["m"], run.Rows[1].Scores["m"] = 0, 0
+	if !reflect.DeepEqual(loaded.Rows, run.Rows) {
+		t.Errorf("rows = %+v, want %+v", loaded.Rows, run.Rows)
+	}
+	if loaded.Name != "run" || loaded.Description != "desc" || !loaded.Pairwise {
+		t.Errorf("run = %+v", loaded)
+	}
+	if loaded.Summary == nil || len(loaded.Summary.Metrics) != 2 || loaded.Summary.Metrics[0].Value != 0.25 {
+		t.Errorf("summary = %+v", loaded.Summary)
+	}
+
+	// The loaded run compares against itself without changes, with the aggregators it was summarized with
+	loadedResults := loaded.PairwiseResults()
+	if loadedResults[1].Errors["n"].Error() != "boom" {
+		t.Errorf("errors = %v", loadedResults[1].Errors)
+	}
+	diff, err := eval.DiffPairwise(loadedResults, loadedResults, eval.DiffOptions{Aggregators: loaded.Aggregators()})
+	if err != nil || len(diff.Metrics) != 2 || diff.Metrics[0].Regressed != 0 || diff.NewErrors != nil {
+		t.Errorf("diff = %+v, %v", diff, err)
+	}
+	if diff.Metrics[0].Aggregator != "sum" {
+		t.Errorf("m is compared with the %s, want the stored sum", diff.Metrics[0].Aggregator)
+	}
+}
+
+func TestListDelete(t *testing.T) {
+	store := New(filepath.Join(t.TempDir(), "runs"))
+	if names, err := store.List(); err != nil || names != nil {
+		t.Errorf("List of a missing directory = %v, %v", names, err)
+	}
+
+	for _, name := range []string{"b", "a"} {
+		if err := store.Save(name, report.Run{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(store.Dir, "notes.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	names, err := store.List()
+	if err != nil || !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Errorf("List = %v, %v, want [a b]", names, err)
+	}
+
+	if err := store.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("a"); err != nil {
+		t.Errorf("deleting a missing run: %v", err)
+	}
+	if _, err := store.Load("a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load of a deleted run = %v, want ErrNotFound", err)
+	}
+}
+
+func TestInvalidNames(t *testing.T) {
+	store := New(t.TempDir())
+	for _, name := range []string{"", ".hidden", "../escape", `a\b`} {
+		if err := store.Save(name, report.Run{}); err == nil {
+			t.Errorf("Save accepted the name %q", name)
+		}
+		if _, err := store.Load(name); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Load of %q = %v, want an invalid name", name, err)
+		}
+	}
+}
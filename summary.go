@@ -0,0 +1,407 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// summaryPercentiles are the percentiles reported in every MetricSummary
+var summaryPercentiles = []float64{5, 25, 75, 90, 95, 99}
+
+// Aggregator reduces the scores a metric produced over a run into a single headline value
+type Aggregator struct {
+	Name      string
+	Aggregate func(scores []float64) float64
+	// Select returns the index of the score Aggregate reduces scores to, it is nil for aggregators blending
+	// several scores like MeanAggregator
+	Select func(scores []float64) int
+}
+
+var (
+	// MeanAggregator reports the arithmetic mean of the scores, it is used when a metric declares no aggregator
+	MeanAggregator = Aggregator{Name: "mean", Aggregate: mean}
+	// MedianAggregator reports the median of the scores
+	MedianAggregator = Aggregator{Name: "median", Aggregate: median}
+	// SumAggregator reports the sum of the scores
+	SumAggregator = Aggregator{Name: "sum", Aggregate: sum}
+	// RateAggregator reports the fraction of non-zero scores
+	RateAggregator = Aggregator{Name: "rate", Aggregate: rate}
+	// MinAggregator reports the smallest score
+	MinAggregator = Aggregator{Name: "min", Aggregate: minimum, Select: argMinimum}
+	// MaxAggregator reports the largest score
+	MaxAggregator = Aggregator{Name: "max", Aggregate: maximum, Select: argMaximum}
+)
+
+// builtinAggregators lists the aggregators LookupAggregator finds by name
+var builtinAggregators = []Aggregator{MeanAggregator, MedianAggregator, SumAggregator, RateAggregator, MinAggregator, MaxAggregator}
+
+// LookupAggregator returns the built-in aggregator with the given name, e.g. the Aggregator of a MetricSummary
+func LookupAggregator(name string) (Aggregator, bool) {
+	for _, aggregator := range builtinAggregators {
+		if aggregator.Name == name {
+			return aggregator, true
+		}
+	}
+	return Aggregator{}, false
+}
+
+// MetricSummary holds the summary statistics of a single metric over a run.
+// Errored and NaN scores are left out of every statistic and only counted.
+// Statistics are zero when no score is left.
+type MetricSummary struct {
+	Metric string
+	// Aggregator is the name of the aggregator that produced Value
+	Aggregator string
+	Value      float64
+	Count      int
+	NaNCount   int
+	ErrorCount int
+	Mean       float64
+	Median     float64
+	// StdDev is the sample standard deviation of the scores
+	StdDev float64
+	Min    float64
+	Max    float64
+	// Percentiles maps names like "p95" to the linearly interpolated percentile of the scores
+	Percentiles map[string]float64
+}
+
+// Summary holds the per-metric summary statistics of an evaluation run
+type Summary struct {
+	Name        string
+	Description string
+	Instances   int
+	Metrics     []MetricSummary
+}
+
+// Metric returns the summary of the named metric
+func (s Summary) Metric(name string) (MetricSummary, bool) {
+	for _, metric := range s.Metrics {
+		if metric.Metric == name {
+			return metric, true
+		}
+	}
+	return MetricSummary{}, false
+}
+
+// Summarize computes the summary statistics of the results of this evaluation,
+// using the aggregator declared by each metric
+func (e *PairwiseEvaluation) Summarize(results []PairwiseResult) Summary {
+	order := e.resultKeys()
+
+	summary := summarize(pairwiseRows(results), order, e.Aggregators())
+	summary.Name = e.Name
+	summary.Description = e.Description
+	return summary
+}
+
+// Summarize computes the summary statistics of the results of this evaluation,
+// using the aggregator declared by each metric
+func (e *PointwiseEvaluation) Summarize(results []PointwiseResult) Summary {
+	order := e.resultKeys()
+
+	summary := summarize(pointwiseRows(results), order, e.Aggregators())
+	summary.Name = e.Name
+	summary.Description = e.Description
+	return summary
+}
+
+// resultKeys returns the result keys of every metric of this evaluation, in metric order
+func (e *PairwiseEvaluation) resultKeys() []string {
+	var keys []string
+	for _, metric := range e.metrics {
+		keys = append(keys, metric.ResultKeys()...)
+	}
+	return keys
+}
+
+// resultKeys returns the result keys of every metric of this evaluation, in metric order
+func (e *PointwiseEvaluation) resultKeys() []string {
+	var keys []string
+	for _, metric := range e.metrics {
+		keys = append(keys, metric.ResultKeys()...)
+	}
+	return keys
+}
+
+// Aggregators returns the aggregator declared by each metric of this evaluation for each of its outputs,
+// keyed by result key
+func (e *PairwiseEvaluation) Aggregators() map[string]Aggregator {
+	aggregators := make(map[string]Aggregator, len(e.metrics))
+	for _, metric := range e.metrics {
+		outputs := metric.Outputs()
+		for i, key := range metric.ResultKeys() {
+			aggregators[key] = metric.OutputAggregator(outputs[i])
+		}
+	}
+	return aggregators
+}
+
+// Aggregators returns the aggregator declared by each metric of this evaluation for each of its outputs,
+// keyed by result key
+func (e *PointwiseEvaluation) Aggregators() map[string]Aggregator {
+	aggregators := make(map[string]Aggregator, len(e.metrics))
+	for _, metric := range e.metrics {
+		outputs := metric.Outputs()
+		for i, key := range metric.ResultKeys() {
+			aggregators[key] = metric.OutputAggregator(outputs[i])
+		}
+	}
+	return aggregators
+}
+
+// SummarizePairwise computes per-metric summary statistics over pairwise results.
+// Metrics missing from aggregators are aggregated with MeanAggregator.
+func SummarizePairwise(results []PairwiseResult, aggregators map[string]Aggregator) Summary {
+	return summarize(pairwiseRows(results), nil, aggregators)
+}
+
+// SummarizePointwise computes per-metric summary statistics over pointwise results.
+// Metrics missing from aggregators are aggregated with MeanAggregator.
+func SummarizePointwise(results []PointwiseResult, aggregators map[string]Aggregator) Summary {
+	return summarize(pointwiseRows(results), nil, aggregators)
+}
+
+// row is the metric outcome of a single result, shared by pairwise and pointwise results
+type row struct {
+	id       string
+	tags     []string
+	metadata map[string]string
+	scores   map[string]float64
+	errors   MetricErrors
+}
+
+func pairwiseRows(results []PairwiseResult) []row {
+	rows := make([]row, len(results))
+	for i, result := range results {
+		instance := result.Instance
+		rows[i] = row{instance.ID, instance.Tags, instance.Metadata, result.MetricResults, result.Errors}
+	}
+	return rows
+}
+
+func pointwiseRows(results []PointwiseResult) []row {
+	rows := make([]row, len(results))
+	for i, result := range results {
+		instance := result.Instance
+		rows[i] = row{instance.ID, instance.Tags, instance.Metadata, result.MetricResults, result.Errors}
+	}
+	return rows
+}
+
+// metricNames returns the metrics present in rows, those in order first and the others sorted by name
+func metricNames(rows []row, order []string) []string {
+	seen := make(map[string]bool)
+	for _, r := range rows {
+		for name := range r.scores {
+			seen[name] = true
+		}
+		for name := range r.errors {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for _, name := range order {
+		if seen[name] {
+			names = append(names, name)
+			delete(seen, name)
+		}
+	}
+
+	rest := make([]string, 0, len(seen))
+	for name := range seen {
+		rest = append(rest, name)
+	}
+	sort.Strings(rest)
+
+	return append(names, rest...)
+}
+
+// metricScores returns the non-NaN scores of a metric in row order, with the number
+// of NaN and errored cells that were skipped
+func metricScores(rows []row, name string) (scores []float64, nans, errs int) {
+	scores = make([]float64, 0, len(rows))
+	for _, r := range rows {
+		if _, failed := r.errors[name]; failed {
+			errs++
+			continue
+		}
+		score, ok := r.scores[name]
+		if !ok {
+			continue
+		}
+		if math.IsNaN(score) {
+			nans++
+			continue
+		}
+		scores = append(scores, score)
+	}
+	return scores, nans, errs
+}
+
+func summarize(rows []row, order []string, aggregators map[string]Aggregator) Summary {
+	summary := Summary{Instances: len(rows)}
+	for _, name := range metricNames(rows, order) {
+		scores, nans, errs := metricScores(rows, name)
+		summary.Metrics = append(summary.Metrics, summarizeScores(name, scores, nans, errs, aggregators[name]))
+	}
+	return summary
+}
+
+func summarizeScores(name string, scores []float64, nans, errs int, aggregator Aggregator) MetricSummary {
+	if aggregator.Aggregate == nil {
+		aggregator = MeanAggregator
+	}
+
+	summary := MetricSummary{
+		Metric:      name,
+		Aggregator:  aggregator.Name,
+		Count:       len(scores),
+		NaNCount:    nans,
+		ErrorCount:  errs,
+		Percentiles: make(map[string]float64, len(summaryPercentiles)),
+	}
+	if len(scores) == 0 {
+		return summary
+	}
+
+	sorted := append([]float64(nil), scores...)
+	sort.Float64s(sorted)
+
+	summary.Value = aggregator.Aggregate(scores)
+	summary.Mean = mean(scores)
+	summary.Median = percentile(sorted, 50)
+	summary.StdDev = stdDev(scores)
+	summary.Min = sorted[0]
+	summary.Max = sorted[len(sorted)-1]
+	for _, p := range summaryPercentiles {
+		summary.Percentiles[percentileName(p)] = percentile(sorted, p)
+	}
+
+	return summary
+}
+
+// percentileName formats a percentile as used in MetricSummary.Percentiles, e.g. "p95" or "p99.9"
+func percentileName(p float64) string {
+	return fmt.Sprintf("p%g", p)
+}
+
+// percentile returns the p-th percentile of sorted scores, linearly interpolating between ranks
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower < 0 {
+		return sorted[0]
+	}
+	if upper >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	if sorted[lower] == sorted[upper] {
+		return sorted[lower]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+func sum(scores []float64) float64 {
+	total := 0.0
+	for _, score := range scores {
+		total += score
+	}
+	return total
+}
+
+func mean(scores []float64) float64 {
+	if len(scores) == 0 {
+		return 0
+	}
+	return sum(scores) / float64(len(scores))
+}
+
+func median(scores []float64) float64 {
+	sorted := append([]float64(nil), scores...)
+	sort.Float64s(sorted)
+	return percentile(sorted, 50)
+}
+
+func rate(scores []float64) float64 {
+	if len(scores) == 0 {
+		return 0
+	}
+	nonZero := 0
+	for _, score := range scores {
+		if score != 0 {
+			nonZero++
+		}
+	}
+	return float64(nonZero) / float64(len(scores))
+}
+
+func minimum(scores []float64) float64 {
+	if len(scores) == 0 {
+		return 0
+	}
+	result := scores[0]
+	for _, score := range scores[1:] {
+		result = math.Min(result, score)
+	}
+	return result
+}
+
+func maximum(scores []float64) float64 {
+	if len(scores) == 0 {
+		return 0
+	}
+	result := scores[0]
+	for _, score := range scores[1:] {
+		result = math.Max(result, score)
+	}
+	return result
+}
+
+// argMinimum returns the index of the first smallest score, or of the first NaN score which minimum reduces to
+func argMinimum(scores []float64) int {
+	return argBest(scores, func(score, best float64) bool { return score < best })
+}
+
+// argMaximum returns the index of the first largest score, or of the first NaN score which maximum reduces to
+func argMaximum(scores []float64) int {
+	return argBest(scores, func(score, best float64) bool { return score > best })
+}
+
+// argBest returns the index of the first score no other score is better than, a NaN score being better than any,
+// or -1 without scores
+func argBest(scores []float64, better func(score, best float64) bool) int {
+	if len(scores) == 0 {
+		return -1
+	}
+	best := 0
+	for i, score := range scores {
+		if math.IsNaN(score) {
+			return i
+		}
+		if better(score, scores[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// stdDev returns the sample standard deviation of scores, 0 for fewer than two scores
+func stdDev(scores []float64) float64 {
+	if len(scores) < 2 {
+		return 0
+	}
+	m := mean(scores)
+	squares := 0.0
+	for _, score := range scores {
+		squares += (score - m) * (score - m)
+	}
+	return math.Sqrt(squares / float64(len(scores)-1))
+}
@@ -0,0 +1,130 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+var errTest = errors.New("test failure")
+
+// pointwiseScores returns pointwise results scoring metric with scores
+func pointwiseScores(metric string, scores []float64) []PointwiseResult {
+	results := make([]PointwiseResult, len(scores))
+	for i, score := range scores {
+		results[i] = PointwiseResult{MetricResults: map[string]float64{metric: score}}
+	}
+	return results
+}
+
+// closeTo reports whether two scores are equal up to rounding errors
+func closeTo(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
+
+func TestSummarizePointwise(t *testing.T) {
+	results := pointwiseScores("m", []float64{3, 1, math.NaN(), 4, 2})
+	results = append(results, PointwiseResult{MetricResults: map[string]float64{}, Errors: MetricErrors{"m": errTest}})
+
+	summary := SummarizePointwise(results, nil)
+	if summary.Instances != 6 || len(summary.Metrics) != 1 {
+		t.Fatalf("summary = %+v, want one metric over 6 instances", summary)
+	}
+	metric := summary.Metrics[0]
+	if metric.Aggregator != "mean" || metric.Count != 4 || metric.NaNCount != 1 || metric.ErrorCount != 1 {
+		t.Errorf("summary of m = %+v", metric)
+	}
+	for name, got := range map[string][2]float64{
+		"value":  {metric.Value, 2.5},
+		"mean":   {metric.Mean, 2.5},
+		"median": {metric.Median, 2.5},
+		"stddev": {metric.StdDev, math.Sqrt(5.0 / 3)},
+		"min":    {metric.Min, 1},
+		"max":    {metric.Max, 4},
+		"p25":    {metric.Percentiles["p25"], 1.75},
+		"p95":    {metric.Percentiles["p95"], 3.85},
+		"p5":     {metric.Percentiles["p5"], 1.15},
+	} {
+		if !closeTo(got[0], got[1]) {
+			t.Errorf("%s = %g, want %g", name, got[0], got[1])
+		}
+	}
+}
+
+func TestAggregators(t *testing.T) {
+	scores := []float64{0, 2, 0, 6}
+	tests := []struct {
+		aggregator Aggregator
+		want       float64
+	}{
+		{MeanAggregator, 2},
+		{MedianAggregator, 1},
+		{SumAggregator, 8},
+		{RateAggregator, 0.5},
+		{MinAggregator, 0},
+		{MaxAggregator, 6},
+	}
+	for _, tt := range tests {
+		if got := tt.aggregator.Aggregate(scores); got != tt.want {
+			t.Errorf("%s of %v = %g, want %g", tt.aggregator.Name, scores, got, tt.want)
+		}
+		if got := tt.aggregator.Aggregate(nil); got != 0 {
+			t.Errorf("%s of no scores = %g, want 0", tt.aggregator.Name, got)
+		}
+		if found, ok := LookupAggregator(tt.aggregator.Name); !ok || found.Aggregate(scores) != tt.want {
+			t.Errorf("LookupAggregator(%q) = %v, %v", tt.aggregator.Name, found.Name, ok)
+		}
+	}
+	if _, ok := LookupAggregator("mode"); ok {
+		t.Error("LookupAggregator found an unknown aggregator")
+	}
+}
+
+func TestAggregatorSelect(t *testing.T) {
+	tests := []struct {
+		aggregator Aggregator
+		scores     []float64
+		want       int
+	}{
+		{MinAggregator, []float64{3, 1, 2, 1}, 1},
+		{MaxAggregator, []float64{3, 1, 3, 2}, 0},
+		{MinAggregator, []float64{3, math.NaN(), 1}, 1},
+		{MaxAggregator, []float64{3, 5, math.NaN()}, 2},
+		{MaxAggregator, nil, -1},
+	}
+	for _, tt := range tests {
+		if got := tt.aggregator.Select(tt.scores); got != tt.want {
+			t.Errorf("%s selection of %v = %d, want %d", tt.aggregator.Name, tt.scores, got, tt.want)
+		}
+	}
+
+	for _, aggregator := range []Aggregator{MeanAggregator, MedianAggregator, SumAggregator, RateAggregator} {
+		if aggregator.Select != nil {
+			t.Errorf("%s selects a single score", aggregator.Name)
+		}
+	}
+}
+
+func TestEvaluationSummarize(t *testing.T) {
+	evaluation := NewPairwiseEvaluation("summary", "", []PairwiseMetric{
+		constantPairwise("z", 1).WithAggregator(SumAggregator),
+		constantPairwise("a", 0),
+	})
+	results, err := evaluation.Run(context.Background(), []Instance{{Prediction: "x"}, {Prediction: "y"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary := evaluation.Summarize(results)
+	if summary.Name != "summary" || len(summary.Metrics) != 2 {
+		t.Fatalf("summary = %+v", summary)
+	}
+	// Metrics keep the order of the evaluation and their aggregators
+	if z := summary.Metrics[0]; z.Metric != "z" || z.Aggregator != "sum" || z.Value != 2 {
+		t.Errorf("summary of z = %+v, want a sum of 2", z)
+	}
+	if a := summary.Metrics[1]; a.Metric != "a" || a.Aggregator != "mean" || a.Value != 0 {
+		t.Errorf("summary of a = %+v, want a mean of 0", a)
+	}
+}
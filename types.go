@@ -1,30 +1,121 @@
 package eval
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"sort"
+)
 
 // Instance represents a single evaluation instance with reference and prediction texts
 type Instance struct {
-	Reference  string
+	// ID identifies the instance in its dataset, it is optional but must be unique within a run when set
+	ID        string
+	Reference string
+	// References holds further acceptable references besides Reference, scored with multi-reference metrics
+	References []string
 	Prediction string
+	// Metadata holds arbitrary attributes of the instance, e.g. the dataset row it came from
+	Metadata map[string]string
+	// Tags label the instance, e.g. with its language or topic
+	Tags []string
+}
+
+// AllReferences returns Reference followed by the further References.
+// An empty Reference is left out when there are further references.
+func (i Instance) AllReferences() []string {
+	if len(i.References) == 0 {
+		return []string{i.Reference}
+	}
+	if i.Reference == "" {
+		return i.References
+	}
+	return append([]string{i.Reference}, i.References...)
+}
+
+// PointwiseInstance represents a single prediction to evaluate on its own
+type PointwiseInstance struct {
+	// ID identifies the instance in its dataset, it is optional but must be unique within a run when set
+	ID         string
+	Prediction string
+	// Metadata holds arbitrary attributes of the instance, e.g. the dataset row it came from
+	Metadata map[string]string
+	// Tags label the instance, e.g. with its language or topic
+	Tags []string
+}
+
+// PointwiseInstances returns the predictions of instances as pointwise instances, keeping their ID, metadata and tags
+func PointwiseInstances(instances []Instance) []PointwiseInstance {
+	pointwise := make([]PointwiseInstance, len(instances))
+	for i, instance := range instances {
+		pointwise[i] = PointwiseInstance{
+			ID:         instance.ID,
+			Prediction: instance.Prediction,
+			Metadata:   instance.Metadata,
+			Tags:       instance.Tags,
+		}
+	}
+	return pointwise
 }
 
 // PairwiseResult represents the output of a pairwise evaluation
 type PairwiseResult struct {
 	Instance      Instance
 	MetricResults map[string]float64
+	// Errors holds the metrics that failed for this instance, only populated in partial failure mode
+	Errors MetricErrors
+	// Details holds the details attached by metrics explaining their scores, only populated for metrics attaching some
+	Details MetricDetails
 }
 
 // PointwiseResult represents the output of a pointwise evaluation
 type PointwiseResult struct {
-	Prediction    string
+	Prediction string
+	// Instance is the evaluated prediction along with its ID, metadata and tags
+	Instance      PointwiseInstance
 	MetricResults map[string]float64
+	// Errors holds the metrics that failed for this prediction, only populated in partial failure mode
+	Errors MetricErrors
+	// Details holds the details attached by metrics explaining their scores, only populated for metrics attaching some
+	Details MetricDetails
+}
+
+// MetricErrors maps metric names to the error that prevented their score from being recorded
+type MetricErrors map[string]error
+
+// InstanceErrors can be returned by a metric function to report failures of individual instances,
+// keyed by their index in the batch, while still returning scores for the remaining instances
+type InstanceErrors map[int]error
+
+// Error implements the error interface, reporting the number of failures and the first failing index
+func (e InstanceErrors) Error() string {
+	indices := make([]int, 0, len(e))
+	for i := range e {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	if len(indices) == 0 {
+		return "no instances failed"
+	}
+	return fmt.Sprintf("%d instances failed, first at index %d: %v", len(indices), indices[0], e[indices[0]])
 }
 
 // PairwiseMetricFunc is a function that computes scores by comparing references and predictions
 type PairwiseMetricFunc func(ctx context.Context, references, predictions []string) ([]float64, error)
 
+// MultiReferencePairwiseMetricFunc is a function that computes scores by comparing each prediction against a set of references
+type MultiReferencePairwiseMetricFunc func(ctx context.Context, references [][]string, predictions []string) ([]float64, error)
+
 // PointwiseMetricFunc is a function that computes scores for predictions
 type PointwiseMetricFunc func(ctx context.Context, predictions []string) ([]float64, error)
 
+// MultiOutputPairwiseMetricFunc is a function that computes several scores by comparing references and predictions,
+// returning the scores of each declared output keyed by its name
+type MultiOutputPairwiseMetricFunc func(ctx context.Context, references, predictions []string) (map[string][]float64, error)
+
+// MultiOutputPointwiseMetricFunc is a function that computes several scores for predictions,
+// returning the scores of each declared output keyed by its name
+type MultiOutputPointwiseMetricFunc func(ctx context.Context, predictions []string) (map[string][]float64, error)
+
 // PairwiseScoreFunc is a function that determines how to calculate the score between reference and prediction scores
 type PairwiseScoreFunc func(referenceScore, predictionScore float64) float64 
\ No newline at end of file